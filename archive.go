@@ -0,0 +1,456 @@
+package fssh
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jarxorg/wfs"
+	"github.com/klauspost/compress/zstd"
+)
+
+// ArchiveSeparator separates an archive file and a name in the archive such as "build.zip!/bin".
+const ArchiveSeparator = "!"
+
+const (
+	// archiveBlockSize is the size of blocks of zip files read by OpenRange.
+	archiveBlockSize = 1 << 20
+	// archiveCacheBlocks is the number of cached blocks of a zip file.
+	archiveCacheBlocks = 16
+)
+
+// archiveOpenFunc returns the function that opens the archive by the extension of the name.
+// If the name is not a supported archive then this returns nil.
+func archiveOpenFunc(name string) func(outer FS, name string) (fs.FS, error) {
+	lower := strings.ToLower(name)
+	hasSuffix := func(exts ...string) bool {
+		for _, ext := range exts {
+			if strings.HasSuffix(lower, ext) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case hasSuffix(".zip"):
+		return openZip
+	case hasSuffix(".tar"):
+		return func(outer FS, name string) (fs.FS, error) {
+			return newTarFS(outer, name, nil)
+		}
+	case hasSuffix(".tar.gz", ".tgz"):
+		return func(outer FS, name string) (fs.FS, error) {
+			return newTarFS(outer, name, func(r io.Reader) (io.ReadCloser, error) {
+				return gzip.NewReader(r)
+			})
+		}
+	case hasSuffix(".tar.zst", ".tzst"):
+		return func(outer FS, name string) (fs.FS, error) {
+			return newTarFS(outer, name, func(r io.Reader) (io.ReadCloser, error) {
+				d, err := zstd.NewReader(r)
+				if err != nil {
+					return nil, err
+				}
+				return d.IOReadCloser(), nil
+			})
+		}
+	}
+	return nil
+}
+
+// splitArchivePath splits the name into the URL of the archive and the name in the archive like
+// "s3://bucket/build.zip!/bin" into "s3://bucket/build.zip" and "bin". The last separator is used
+// so an archive in an archive is opened by the FS of the outer archive.
+func splitArchivePath(name string) (archiveUrl string, filename string, ok bool) {
+	for i := strings.LastIndex(name, ArchiveSeparator); i > 0; i = strings.LastIndex(name[:i], ArchiveSeparator) {
+		rest := name[i+len(ArchiveSeparator):]
+		if rest != "" && rest[0] != '/' {
+			continue
+		}
+		if archiveOpenFunc(name[:i]) != nil {
+			return name[:i], path.Clean(strings.TrimLeft(rest, "/")), true
+		}
+	}
+	return "", "", false
+}
+
+// archiveFS is a read-only file system of files in an archive such as zip and tar.gz.
+// The archive is read at the first access.
+type archiveFS struct {
+	outer FS
+	name  string
+	once  sync.Once
+	fsys  fs.FS
+	err   error
+}
+
+var (
+	_ fs.ReadDirFS     = (*archiveFS)(nil)
+	_ fs.StatFS        = (*archiveFS)(nil)
+	_ wfs.RemoveFileFS = (*archiveFS)(nil)
+)
+
+// newArchiveFS returns a file system of the archive of the name in the outer.
+func newArchiveFS(outer FS, name string) *archiveFS {
+	return &archiveFS{outer: outer, name: name}
+}
+
+func (fsys *archiveFS) archive() (fs.FS, error) {
+	fsys.once.Do(func() {
+		open := archiveOpenFunc(fsys.name)
+		if open == nil {
+			fsys.err = &fs.PathError{Op: "open", Path: fsys.name, Err: errors.New("unsupported archive")}
+			return
+		}
+		fsys.fsys, fsys.err = open(fsys.outer, fsys.name)
+	})
+	return fsys.fsys, fsys.err
+}
+
+// Open opens the named file in the archive.
+func (fsys *archiveFS) Open(name string) (fs.File, error) {
+	a, err := fsys.archive()
+	if err != nil {
+		return nil, err
+	}
+	return a.Open(name)
+}
+
+// ReadDir reads the named directory in the archive.
+func (fsys *archiveFS) ReadDir(dir string) ([]fs.DirEntry, error) {
+	a, err := fsys.archive()
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadDir(a, dir)
+}
+
+// Stat returns a FileInfo describing the file in the archive.
+func (fsys *archiveFS) Stat(name string) (fs.FileInfo, error) {
+	a, err := fsys.archive()
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(a, name)
+}
+
+// MkdirAll returns ErrReadOnly.
+func (fsys *archiveFS) MkdirAll(dir string, mode fs.FileMode) error {
+	return &fs.PathError{Op: "mkdir", Path: dir, Err: ErrReadOnly}
+}
+
+// CreateFile returns ErrReadOnly.
+func (fsys *archiveFS) CreateFile(name string, mode fs.FileMode) (wfs.WriterFile, error) {
+	return nil, &fs.PathError{Op: "create", Path: name, Err: ErrReadOnly}
+}
+
+// WriteFile returns ErrReadOnly.
+func (fsys *archiveFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	return 0, &fs.PathError{Op: "write", Path: name, Err: ErrReadOnly}
+}
+
+// RemoveFile returns ErrReadOnly.
+func (fsys *archiveFS) RemoveFile(name string) error {
+	return &fs.PathError{Op: "remove", Path: name, Err: ErrReadOnly}
+}
+
+// RemoveAll returns ErrReadOnly.
+func (fsys *archiveFS) RemoveAll(name string) error {
+	return &fs.PathError{Op: "remove", Path: name, Err: ErrReadOnly}
+}
+
+// openZip opens the zip file of the name in the outer. Only the central directory and
+// the files to read are read from the outer.
+func openZip(outer FS, name string) (fs.FS, error) {
+	info, err := fs.Stat(outer, name)
+	if err != nil {
+		return nil, err
+	}
+	r := &blockReaderAt{fsys: outer, name: name, size: info.Size(), blocks: map[int64][]byte{}}
+	zr, err := zip.NewReader(r, info.Size())
+	// NOTE: Insecure names such as "../a" are ignored by fs.FS of zip.Reader.
+	if err != nil && !errors.Is(err, zip.ErrInsecurePath) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return zr, nil
+}
+
+// blockReaderAt is io.ReaderAt that reads blocks of the file by OpenRange and caches them.
+type blockReaderAt struct {
+	fsys   FS
+	name   string
+	size   int64
+	mutex  sync.Mutex
+	blocks map[int64][]byte
+	order  []int64
+}
+
+func (r *blockReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		if pos >= r.size {
+			return n, io.EOF
+		}
+		block, err := r.block(pos / archiveBlockSize)
+		if err != nil {
+			return n, err
+		}
+		i := pos % archiveBlockSize
+		if i >= int64(len(block)) {
+			return n, io.ErrUnexpectedEOF
+		}
+		n += copy(p[n:], block[i:])
+	}
+	return n, nil
+}
+
+func (r *blockReaderAt) block(index int64) ([]byte, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if block, ok := r.blocks[index]; ok {
+		return block, nil
+	}
+	rc, err := OpenRange(r.fsys, r.name, index*archiveBlockSize, archiveBlockSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	block, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.order) >= archiveCacheBlocks {
+		delete(r.blocks, r.order[0])
+		r.order = r.order[1:]
+	}
+	r.blocks[index] = block
+	r.order = append(r.order, index)
+	return block, nil
+}
+
+// tarEntry is a file or directory in a tar file.
+type tarEntry struct {
+	info fs.FileInfo
+	// offset is the offset of the content in the tar file if it is not compressed otherwise -1.
+	offset int64
+}
+
+// tarFS is a file system of a tar file. Headers are read when the tar file is opened. Contents are
+// read by OpenRange of the outer if the tar file is not compressed otherwise by reading the tar
+// file from the beginning to the file.
+type tarFS struct {
+	outer      FS
+	name       string
+	decompress func(r io.Reader) (io.ReadCloser, error)
+	entries    map[string]*tarEntry
+	children   map[string][]string
+}
+
+var (
+	_ fs.ReadDirFS = (*tarFS)(nil)
+	_ fs.StatFS    = (*tarFS)(nil)
+)
+
+// newTarFS reads headers of the tar file of the name in the outer. If the decompress is not nil
+// then the tar file is decompressed by it.
+func newTarFS(outer FS, name string, decompress func(r io.Reader) (io.ReadCloser, error)) (*tarFS, error) {
+	fsys := &tarFS{
+		outer:      outer,
+		name:       name,
+		decompress: decompress,
+		entries:    map[string]*tarEntry{".": {info: &objectInfo{name: ".", isDir: true}, offset: -1}},
+		children:   map[string][]string{},
+	}
+	s, err := fsys.openStream()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	defer s.Close()
+	for {
+		entryName, hdr, err := s.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		offset := int64(-1)
+		if decompress == nil {
+			offset = s.count.n
+		}
+		// NOTE: Links and special files are ignored.
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			fsys.add(entryName, hdr.FileInfo(), offset)
+		case tar.TypeDir:
+			fsys.add(entryName, hdr.FileInfo(), -1)
+		}
+	}
+	for _, names := range fsys.children {
+		sort.Strings(names)
+	}
+	return fsys, nil
+}
+
+// tarStream reads a tar file from the beginning.
+type tarStream struct {
+	*tar.Reader
+	count   *countReader
+	closers []io.Closer
+}
+
+func (fsys *tarFS) openStream() (*tarStream, error) {
+	f, err := fsys.outer.Open(fsys.name)
+	if err != nil {
+		return nil, err
+	}
+	s := &tarStream{count: &countReader{r: f}, closers: []io.Closer{f}}
+	var r io.Reader = s.count
+	if fsys.decompress != nil {
+		dr, err := fsys.decompress(r)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		s.closers = append(s.closers, dr)
+		r = dr
+	}
+	s.Reader = tar.NewReader(r)
+	return s, nil
+}
+
+// next returns the next header and the cleaned name. The Reader reads the content of the file.
+func (s *tarStream) next() (string, *tar.Header, error) {
+	for {
+		hdr, err := s.Next()
+		if err != nil && !errors.Is(err, tar.ErrInsecurePath) {
+			return "", nil, err
+		}
+		if name := cleanArchiveName(hdr.Name); name != "." {
+			return name, hdr, nil
+		}
+	}
+}
+
+func (s *tarStream) Close() error {
+	var errs []error
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		errs = append(errs, s.closers[i].Close())
+	}
+	return errors.Join(errs...)
+}
+
+// add adds the entry of the name and implicit parent directories.
+func (fsys *tarFS) add(name string, info fs.FileInfo, offset int64) {
+	if _, ok := fsys.entries[name]; !ok {
+		dir := path.Dir(name)
+		if _, ok := fsys.entries[dir]; !ok {
+			fsys.add(dir, &objectInfo{name: path.Base(dir), isDir: true}, -1)
+		}
+		fsys.children[dir] = append(fsys.children[dir], name)
+	}
+	fsys.entries[name] = &tarEntry{info: info, offset: offset}
+}
+
+func (fsys *tarFS) entry(op, name string) (*tarEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	e, ok := fsys.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return e, nil
+}
+
+// Open opens the named file.
+func (fsys *tarFS) Open(name string) (fs.File, error) {
+	e, err := fsys.entry("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if e.info.IsDir() {
+		return &dirFile{fsys: fsys, name: name, info: e.info}, nil
+	}
+	if e.offset >= 0 {
+		rc, err := OpenRange(fsys.outer, fsys.name, e.offset, e.info.Size())
+		if err != nil {
+			return nil, err
+		}
+		return &readerFile{ReadCloser: rc, info: e.info}, nil
+	}
+	// NOTE: A compressed tar file is read from the beginning to the file.
+	s, err := fsys.openStream()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	for {
+		entryName, hdr, err := s.next()
+		if err != nil {
+			s.Close()
+			if err == io.EOF {
+				err = fs.ErrNotExist
+			}
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		if entryName == name && hdr.Typeflag == tar.TypeReg {
+			return &readerFile{ReadCloser: &limitedReadCloser{Reader: s.Reader, Closer: s}, info: e.info}, nil
+		}
+	}
+}
+
+// ReadDir reads the named directory and returns a list of directory entries sorted by filename.
+func (fsys *tarFS) ReadDir(dir string) ([]fs.DirEntry, error) {
+	e, err := fsys.entry("readdir", dir)
+	if err != nil {
+		return nil, err
+	}
+	if !e.info.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: dir, Err: errors.New("not a directory")}
+	}
+	names := fsys.children[dir]
+	entries := make([]fs.DirEntry, len(names))
+	for i, name := range names {
+		entries[i] = fs.FileInfoToDirEntry(fsys.entries[name].info)
+	}
+	return entries, nil
+}
+
+// Stat returns a FileInfo describing the file.
+func (fsys *tarFS) Stat(name string) (fs.FileInfo, error) {
+	e, err := fsys.entry("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return e.info, nil
+}
+
+// cleanArchiveName returns the name in the archive as a valid path of fs.FS.
+// Leading "/" and "../" are removed.
+func cleanArchiveName(name string) string {
+	name = strings.TrimLeft(path.Clean("/"+name), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// countReader counts bytes read from r.
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,284 @@
+package fssh
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var testArchiveFiles = []struct {
+	name string
+	data string
+}{
+	{name: "a.txt", data: "a"},
+	{name: "dir/", data: ""},
+	{name: "dir/b.txt", data: "bb"},
+	{name: "./dir/sub/c.txt", data: "ccc"},
+}
+
+// writeTestArchive writes the archive of testArchiveFiles and returns the content.
+func writeTestArchive(t *testing.T, name string) []byte {
+	buf := &bytes.Buffer{}
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		zw := zip.NewWriter(buf)
+		for _, f := range testArchiveFiles {
+			w, err := zw.Create(f.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := io.WriteString(w, f.data); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// NOTE: Larger than archiveBlockSize to read multiple blocks.
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: "big.bin", Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(bytes.Repeat([]byte("0123456789"), archiveBlockSize/4)); err != nil {
+			t.Fatal(err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	case strings.HasSuffix(name, ".tar"):
+		tw := tar.NewWriter(buf)
+		for _, f := range testArchiveFiles {
+			hdr := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.data)), Typeflag: tar.TypeReg}
+			if strings.HasSuffix(f.name, "/") {
+				hdr.Mode, hdr.Typeflag = 0755, tar.TypeDir
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := io.WriteString(tw, f.data); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: "link", Linkname: "a.txt", Typeflag: tar.TypeSymlink}); err != nil {
+			t.Fatal(err)
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	case strings.HasSuffix(name, ".tar.gz"):
+		zw := gzip.NewWriter(buf)
+		zw.Write(writeTestArchive(t, strings.TrimSuffix(name, ".gz")))
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	case strings.HasSuffix(name, ".tar.zst"):
+		zw, err := zstd.NewWriter(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zw.Write(writeTestArchive(t, strings.TrimSuffix(name, ".zst")))
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	t.Fatalf("unsupported archive: %s", name)
+	return nil
+}
+
+func TestSplitArchivePath(t *testing.T) {
+	tests := []struct {
+		name           string
+		wantArchiveUrl string
+		wantFilename   string
+		wantOk         bool
+	}{
+		{
+			name:           "s3://bucket/build.zip!/bin/app",
+			wantArchiveUrl: "s3://bucket/build.zip",
+			wantFilename:   "bin/app",
+			wantOk:         true,
+		}, {
+			name:           "build.ZIP!",
+			wantArchiveUrl: "build.ZIP",
+			wantFilename:   ".",
+			wantOk:         true,
+		}, {
+			name:           "dir/src.tgz!/",
+			wantArchiveUrl: "dir/src.tgz",
+			wantFilename:   ".",
+			wantOk:         true,
+		}, {
+			name:           "a.tar.zst!/b.zip!/c.txt",
+			wantArchiveUrl: "a.tar.zst!/b.zip",
+			wantFilename:   "c.txt",
+			wantOk:         true,
+		}, {
+			name:           "a.zip!/b!/c.txt",
+			wantArchiveUrl: "a.zip",
+			wantFilename:   "b!/c.txt",
+			wantOk:         true,
+		}, {
+			name: "hello!.txt",
+		}, {
+			name: "a.txt!/b",
+		}, {
+			name: "a.zip!b",
+		},
+	}
+	for i, test := range tests {
+		gotArchiveUrl, gotFilename, gotOk := splitArchivePath(test.name)
+		if gotArchiveUrl != test.wantArchiveUrl || gotFilename != test.wantFilename || gotOk != test.wantOk {
+			t.Errorf("tests[%d]: got %q, %q, %v; want %q, %q, %v", i,
+				gotArchiveUrl, gotFilename, gotOk, test.wantArchiveUrl, test.wantFilename, test.wantOk)
+		}
+	}
+}
+
+func TestArchiveFS(t *testing.T) {
+	tmpDir := t.TempDir()
+	names := []string{"test.zip", "test.tar", "test.tar.gz", "test.tar.zst"}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), writeTestArchive(t, name), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, name := range names {
+		fsys, _, _, dir, err := NewFS(filepath.ToSlash(filepath.Join(tmpDir, name)) + "!/")
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if dir != "." {
+			t.Errorf("tests[%d]: got dir %s; want .", i, dir)
+		}
+		expected := []string{"a.txt", "dir/b.txt", "dir/sub/c.txt"}
+		if name == "test.zip" {
+			expected = append(expected, "big.bin")
+		}
+		if err := fstest.TestFS(fsys, expected...); err != nil {
+			t.Errorf("tests[%d]: %s: %v", i, name, err)
+		}
+		got, err := fs.ReadFile(fsys, "dir/sub/c.txt")
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if string(got) != "ccc" {
+			t.Errorf("tests[%d]: got %q; want %q", i, got, "ccc")
+		}
+		if _, err := fs.Stat(fsys, "link"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("tests[%d]: got err %v; want %v", i, err, fs.ErrNotExist)
+		}
+	}
+}
+
+func TestArchiveFSNested(t *testing.T) {
+	tmpDir := t.TempDir()
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	inner := writeTestArchive(t, "inner.zip")
+	if err := tw.WriteHeader(&tar.Header{Name: "inner.zip", Mode: 0644, Size: int64(len(inner))}); err != nil {
+		t.Fatal(err)
+	}
+	tw.Write(inner)
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "outer.tar"), buf.Bytes(), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys, _, _, name, err := NewFS(filepath.ToSlash(filepath.Join(tmpDir, "outer.tar")) + "!/inner.zip!/dir/b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "bb" {
+		t.Errorf("got %q; want %q", got, "bb")
+	}
+}
+
+func TestArchiveFSErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "broken.zip"), []byte("broken"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	fsys, _, _, _, err := NewFS(filepath.ToSlash(filepath.Join(tmpDir, "broken.zip")) + "!/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.Stat(fsys, "a.txt"); !errors.Is(err, zip.ErrFormat) {
+		t.Errorf("got err %v; want %v", err, zip.ErrFormat)
+	}
+
+	fsys, _, _, _, err = NewFS(filepath.ToSlash(filepath.Join(tmpDir, "none.tar")) + "!/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.ReadDir(fsys, "."); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got err %v; want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestArchiveFSReadOnly(t *testing.T) {
+	fsys := newArchiveFS(nil, "test.zip")
+	errs := []error{
+		fsys.MkdirAll("dir", os.ModePerm),
+		fsys.RemoveFile("a.txt"),
+		fsys.RemoveAll("dir"),
+	}
+	_, err := fsys.CreateFile("a.txt", os.ModePerm)
+	errs = append(errs, err)
+	_, err = fsys.WriteFile("a.txt", []byte("a"), os.ModePerm)
+	errs = append(errs, err)
+	for i, err := range errs {
+		if !errors.Is(err, ErrReadOnly) {
+			t.Errorf("errs[%d]: got %v; want %v", i, err, ErrReadOnly)
+		}
+	}
+}
+
+func TestShellResolveArchivePath(t *testing.T) {
+	sh := &Shell{Protocol: "s3://", Host: "bucket", Dir: "dir"}
+	tests := []struct {
+		nameUrl string
+		want    string
+	}{
+		{
+			nameUrl: "build.zip!/bin",
+			want:    "s3://bucket/dir/build.zip!/bin",
+		}, {
+			nameUrl: "./build.zip!",
+			want:    "s3://bucket/dir/./build.zip!",
+		}, {
+			nameUrl: "gs://other/build.zip!/bin",
+			want:    "gs://other/build.zip!/bin",
+		}, {
+			nameUrl: "/tmp/build.zip!/bin",
+			want:    "/tmp/build.zip!/bin",
+		}, {
+			nameUrl: "bin",
+			want:    "bin",
+		},
+	}
+	for i, test := range tests {
+		got := sh.ResolveArchivePath(test.nameUrl)
+		if got != test.want {
+			t.Errorf("tests[%d]: got %s; want %s", i, got, test.want)
+		}
+	}
+}
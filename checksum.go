@@ -0,0 +1,136 @@
+package fssh
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// ErrChecksumMismatch represents that the checksum of a file does not match.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Checksum holds digests of a content.
+type Checksum struct {
+	MD5    []byte
+	CRC32C uint32
+	SHA256 []byte
+}
+
+// ChecksumWriter computes MD5, CRC32C and SHA-256 of written bytes.
+type ChecksumWriter struct {
+	md5    hash.Hash
+	crc32c hash.Hash32
+	sha256 hash.Hash
+}
+
+var _ io.Writer = (*ChecksumWriter)(nil)
+
+// NewChecksumWriter creates a new ChecksumWriter.
+func NewChecksumWriter() *ChecksumWriter {
+	return &ChecksumWriter{
+		md5:    md5.New(),
+		crc32c: crc32.New(crc32cTable),
+		sha256: sha256.New(),
+	}
+}
+
+// Write writes p to all hashes.
+func (w *ChecksumWriter) Write(p []byte) (int, error) {
+	w.md5.Write(p)
+	w.crc32c.Write(p)
+	w.sha256.Write(p)
+	return len(p), nil
+}
+
+// Reset resets all hashes.
+func (w *ChecksumWriter) Reset() {
+	w.md5.Reset()
+	w.crc32c.Reset()
+	w.sha256.Reset()
+}
+
+// Checksum returns digests of written bytes.
+func (w *ChecksumWriter) Checksum() *Checksum {
+	return &Checksum{
+		MD5:    w.md5.Sum(nil),
+		CRC32C: w.crc32c.Sum32(),
+		SHA256: w.sha256.Sum(nil),
+	}
+}
+
+// FileChecksum reads the named file and returns the checksum.
+func FileChecksum(fsys FS, name string) (*Checksum, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	w := NewChecksumWriter()
+	if _, err := io.Copy(w, f); err != nil {
+		return nil, err
+	}
+	return w.Checksum(), nil
+}
+
+// VerifyChecksum compares the checksum with the named file. The checksum in the metadata of the object
+// is used if available such as crc32c of GCS and ETag of S3, otherwise this reads the file again.
+// If the checksum does not match then this returns an error wrapping ErrChecksumMismatch.
+func VerifyChecksum(fsys FS, name string, sum *Checksum) error {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return err
+	}
+	if ok, err := verifyMetadata(name, info, sum); ok {
+		return err
+	}
+	got, err := FileChecksum(fsys, name)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got.SHA256, sum.SHA256) {
+		return checksumMismatch(name, "sha256", hex.EncodeToString(got.SHA256), hex.EncodeToString(sum.SHA256))
+	}
+	return nil
+}
+
+// verifyMetadata compares the checksum with the metadata of the object.
+// If the metadata has no available checksum then this returns false.
+func verifyMetadata(name string, info fs.FileInfo, sum *Checksum) (bool, error) {
+	switch sys := info.Sys().(type) {
+	case *storage.ObjectAttrs:
+		if sys.CRC32C != sum.CRC32C {
+			return true, checksumMismatch(name, "crc32c", fmt.Sprintf("%08x", sys.CRC32C), fmt.Sprintf("%08x", sum.CRC32C))
+		}
+		return true, nil
+	case *s3.HeadObjectOutput:
+		// NOTE: ETag is not MD5 if the object was uploaded by multipart or encrypted by KMS.
+		etag := strings.Trim(aws.StringValue(sys.ETag), `"`)
+		if etag == "" || strings.Contains(etag, "-") || aws.StringValue(sys.ServerSideEncryption) == s3.ServerSideEncryptionAwsKms {
+			return false, nil
+		}
+		if want := hex.EncodeToString(sum.MD5); etag != want {
+			return true, checksumMismatch(name, "md5", etag, want)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func checksumMismatch(name, algorithm, got, want string) error {
+	return fmt.Errorf("%w: %s: %s %s; want %s", ErrChecksumMismatch, name, algorithm, got, want)
+}
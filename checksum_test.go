@@ -0,0 +1,113 @@
+package fssh
+
+import (
+	"encoding/hex"
+	"errors"
+	"os"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/jarxorg/wfs/memfs"
+)
+
+func testChecksum(t *testing.T, content string) *Checksum {
+	w := NewChecksumWriter()
+	if _, err := w.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	return w.Checksum()
+}
+
+func TestChecksumWriter(t *testing.T) {
+	got := testChecksum(t, "test")
+	if want := "098f6bcd4621d373cade4e832627b4f6"; hex.EncodeToString(got.MD5) != want {
+		t.Errorf("got md5 %x; want %s", got.MD5, want)
+	}
+	if want := uint32(0x86a072c0); got.CRC32C != want {
+		t.Errorf("got crc32c %08x; want %08x", got.CRC32C, want)
+	}
+	if want := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"; hex.EncodeToString(got.SHA256) != want {
+		t.Errorf("got sha256 %x; want %s", got.SHA256, want)
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	fsys := memfs.New()
+	if _, err := fsys.WriteFile("test.txt", []byte("test"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		content string
+		errstr  string
+	}{
+		{
+			content: "test",
+		}, {
+			content: "TEST",
+			errstr:  "checksum mismatch: test.txt: sha256 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08; want 94ee059335e587e501cc4bf90613e0814f00a7b08bc7c648fd865a2af6a22cc2",
+		},
+	}
+	for i, test := range tests {
+		err := VerifyChecksum(fsys, "test.txt", testChecksum(t, test.content))
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+			if !errors.Is(err, ErrChecksumMismatch) {
+				t.Errorf("tests[%d]: got err %v; want %v", i, err, ErrChecksumMismatch)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("tests[%d]: err %v", i, err)
+		}
+	}
+}
+
+func TestVerifyMetadata(t *testing.T) {
+	sum := testChecksum(t, "test")
+	tests := []struct {
+		sys    any
+		wantOK bool
+		errstr string
+	}{
+		{
+			sys:    &storage.ObjectAttrs{CRC32C: 0x86a072c0},
+			wantOK: true,
+		}, {
+			sys:    &storage.ObjectAttrs{CRC32C: 1},
+			wantOK: true,
+			errstr: "checksum mismatch: test.txt: crc32c 00000001; want 86a072c0",
+		}, {
+			sys:    &s3.HeadObjectOutput{ETag: aws.String(`"098f6bcd4621d373cade4e832627b4f6"`)},
+			wantOK: true,
+		}, {
+			sys:    &s3.HeadObjectOutput{ETag: aws.String(`"00000000000000000000000000000000"`)},
+			wantOK: true,
+			errstr: "checksum mismatch: test.txt: md5 00000000000000000000000000000000; want 098f6bcd4621d373cade4e832627b4f6",
+		}, {
+			sys:    &s3.HeadObjectOutput{ETag: aws.String(`"00000000000000000000000000000000-2"`)},
+			wantOK: false,
+		}, {
+			sys:    nil,
+			wantOK: false,
+		},
+	}
+	for i, test := range tests {
+		ok, err := verifyMetadata("test.txt", &objectInfo{sys: test.sys}, sum)
+		if ok != test.wantOK {
+			t.Errorf("tests[%d]: got ok %v; want %v", i, ok, test.wantOK)
+		}
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("tests[%d]: err %v", i, err)
+		}
+	}
+}
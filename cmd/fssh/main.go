@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"os"
 
@@ -10,6 +11,9 @@ import (
 
 func main() {
 	if err := fssh.Main(os.Args); err != nil {
+		if errors.Is(err, fssh.ErrCommandFailed) {
+			os.Exit(1)
+		}
 		log.Fatal(err)
 	}
 }
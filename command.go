@@ -1,6 +1,7 @@
 package fssh
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
@@ -19,8 +20,8 @@ type Command interface {
 	Description() string
 	// FlagSet returns the flagSet of a command.
 	FlagSet() *flag.FlagSet
-	// Exec executes a command.
-	Exec(sh *Shell) error
+	// Exec executes a command. The ctx is cancelled when the user interrupts the command.
+	Exec(ctx context.Context, sh *Shell) error
 	// Usage writes help usage.
 	Usage(w io.Writer)
 	// AutoCompleter returns a AutoCompleter if the command supports auto completion.
@@ -1,10 +1,10 @@
 package command
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
-	"io/fs"
 
 	"github.com/jarxorg/fssh"
 )
@@ -37,25 +37,59 @@ func (c *cat) FlagSet() *flag.FlagSet {
 func (c *cat) Reset() {
 }
 
-func (c *cat) Exec(sh *fssh.Shell) error {
+func (c *cat) Exec(ctx context.Context, sh *fssh.Shell) error {
 	args := c.FlagSet().Args()
 	if len(args) == 0 {
 		c.Usage(sh.Stderr)
 		return nil
 	}
-	fsys, name, err := sh.SubFS(args[0])
+	for _, arg := range args {
+		if err := c.cat(ctx, sh, arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *cat) cat(ctx context.Context, sh *fssh.Shell, nameUrl string) error {
+	if nameUrl == "-" {
+		_, err := io.Copy(sh.Stdout, &contextReader{ctx: ctx, r: sh.Stdin})
+		return err
+	}
+	fsys, name, err := sh.SubFS(nameUrl)
 	if err != nil {
 		return err
 	}
-	bin, err := fs.ReadFile(fsys, name)
+	f, err := fsys.Open(name)
 	if err != nil {
 		return err
 	}
-	fmt.Fprintf(sh.Stdout, "%s", bin)
-	if len(bin) > 0 && bin[len(bin)-1] != '\n' {
-		fmt.Fprint(sh.Stdout, "\n")
+	defer f.Close()
+
+	w := &lastByteWriter{w: sh.Stdout}
+	if _, err := io.Copy(w, &contextReader{ctx: ctx, r: f}); err != nil {
+		return err
 	}
-	return nil
+	if w.n > 0 && w.last != '\n' {
+		_, err = fmt.Fprint(sh.Stdout, "\n")
+	}
+	return err
+}
+
+// lastByteWriter remembers the last written byte.
+type lastByteWriter struct {
+	w    io.Writer
+	n    int64
+	last byte
+}
+
+func (w *lastByteWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if n > 0 {
+		w.n += int64(n)
+		w.last = p[n-1]
+	}
+	return n, err
 }
 
 func (c *cat) AutoCompleter() fssh.AutoCompleterFunc {
@@ -68,10 +102,12 @@ func (c *cat) autoComplete(sh *fssh.Shell, arg string) ([]string, error) {
 
 func (c *cat) Usage(w io.Writer) {
 	name := c.Name()
-	fmt.Fprintf(w, "Usage:\n  %s [file]\n", name)
+	fmt.Fprintf(w, "Usage:\n  %s [file]...\n", name)
 	fmt.Fprintln(w, "Examples:")
 	fmt.Fprintf(w, "  %s FILE\n", name)
 	fmt.Fprintf(w, "  %s (s3|gs)://BUCKET/DIR/FILE\n", name)
+	fmt.Fprintf(w, "  %s FILE1 FILE2\n", name)
+	fmt.Fprintf(w, "  ! ls -l | %s -       # Read stdin\n", name)
 }
 
 func init() {
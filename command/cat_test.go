@@ -0,0 +1,56 @@
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCat(t *testing.T) {
+	files := map[string]string{
+		"a.txt":     "a\n",
+		"b.txt":     "b",
+		"empty.txt": "",
+	}
+	tests := []struct {
+		line   string
+		stdin  string
+		want   string
+		errstr string
+	}{
+		{
+			line: "cat a.txt",
+			want: "a\n",
+		}, {
+			line: "cat b.txt a.txt empty.txt b.txt",
+			want: "b\na\nb\n",
+		}, {
+			line:  "cat a.txt - b.txt",
+			stdin: "in\n",
+			want:  "a\nin\nb\n",
+		}, {
+			line: "cat empty.txt",
+			want: "",
+		}, {
+			line:   "cat a.txt missing.txt b.txt",
+			want:   "a\n",
+			errstr: "missing.txt",
+		},
+	}
+	for i, test := range tests {
+		setTestHome(t, nil)
+		sh, stdout, _ := newTestShell(t, "mem://", files)
+		sh.Stdin = strings.NewReader(test.stdin)
+
+		err := execTestLine(sh, test.line)
+		if test.errstr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.errstr) {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+		} else if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if got := stdout.String(); got != test.want {
+			t.Errorf("tests[%d]: got %q; want %q", i, got, test.want)
+		}
+	}
+}
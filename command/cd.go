@@ -1,6 +1,7 @@
 package command
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
@@ -38,16 +39,16 @@ func (c *cd) FlagSet() *flag.FlagSet {
 func (c *cd) Reset() {
 }
 
-func (c *cd) Exec(sh *fssh.Shell) error {
+func (c *cd) Exec(ctx context.Context, sh *fssh.Shell) error {
 	args := c.FlagSet().Args()
 	if len(args) == 0 {
 		sh.Dir = ""
 		sh.UpdatePrompt()
 		return nil
 	}
-	dir := args[0]
+	dir := sh.ResolveArchivePath(args[0])
 	if !fssh.IsCurrentPath(dir) {
-		fsys, protocol, host, subDir, err := fssh.NewDirFS(dir)
+		fsys, protocol, host, subDir, err := sh.NewDirFS(dir)
 		if err != nil {
 			return err
 		}
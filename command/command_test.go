@@ -0,0 +1,95 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/jarxorg/fssh"
+)
+
+// newTestShell returns a script shell of the dirUrl that has the files. If the dirUrl is empty then
+// the shell is of a temporary local directory. Names ending with "/" are empty directories.
+func newTestShell(t *testing.T, dirUrl string, files map[string]string) (sh *fssh.Shell, stdout *bytes.Buffer, stderr *bytes.Buffer) {
+	if dirUrl == "" {
+		dirUrl = t.TempDir()
+	}
+	sh, err := fssh.NewScriptShell(dirUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFiles(t, sh.FS, sh.Dir, files)
+	stdout, stderr = new(bytes.Buffer), new(bytes.Buffer)
+	sh.Stdout, sh.Stderr = stdout, stderr
+	return sh, stdout, stderr
+}
+
+// setTestHome sets $HOME to a temporary local directory that has the files to use it by "~/".
+// It returns the file system and the directory of the home.
+func setTestHome(t *testing.T, files map[string]string) (fssh.FS, string) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	fsys, _, _, dir, err := fssh.NewFS(homeDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFiles(t, fsys, dir, files)
+	return fsys, dir
+}
+
+func writeTestFiles(t *testing.T, fsys fssh.FS, dir string, files map[string]string) {
+	for name, content := range files {
+		if strings.HasSuffix(name, "/") {
+			if err := fsys.MkdirAll(path.Join(dir, name), os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := fsys.MkdirAll(path.Dir(path.Join(dir, name)), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fsys.WriteFile(path.Join(dir, name), []byte(content), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readTestFiles returns contents of files in the dir by names relative to the dir.
+// Empty directories are returned as names ending with "/".
+func readTestFiles(t *testing.T, fsys fs.FS, dir string) map[string]string {
+	files := map[string]string{}
+	err := fs.WalkDir(fsys, dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, dir), "/")
+		if d.IsDir() {
+			entries, err := fs.ReadDir(fsys, name)
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 && rel != "" {
+				files[rel+"/"] = ""
+			}
+			return nil
+		}
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		files[rel] = string(b)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func execTestLine(sh *fssh.Shell, line string) error {
+	return sh.ExecLine(context.Background(), line)
+}
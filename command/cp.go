@@ -1,6 +1,8 @@
 package command
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -8,8 +10,17 @@ import (
 	"os"
 	"path"
 	"strings"
+	"sync"
 
 	"github.com/jarxorg/fssh"
+	"github.com/jarxorg/wfs"
+)
+
+const (
+	// defaultJobs is the default number of files transferred in parallel.
+	defaultJobs = 4
+	// verifyAttempts is the number of attempts to copy a file if the checksum does not match.
+	verifyAttempts = 3
 )
 
 type cp struct {
@@ -17,6 +28,17 @@ type cp struct {
 	isRecursive bool
 	isForce     bool
 	isDryRun    bool
+	isResume    bool
+	isVerify    bool
+	jobs        int
+	journal     *fssh.Journal
+	progress    *fssh.Progress
+	stdout      io.Writer
+	stderr      io.Writer
+	filter      fssh.Filter
+	mutex       sync.Mutex
+	skipped     int
+	resumed     int
 }
 
 func newCp() fssh.Command {
@@ -38,6 +60,10 @@ func (c *cp) FlagSet() *flag.FlagSet {
 		s.BoolVar(&c.isRecursive, "r", false, "copy directories recursively")
 		s.BoolVar(&c.isForce, "f", false, "forse")
 		s.BoolVar(&c.isDryRun, "d", false, "dry run")
+		s.IntVar(&c.jobs, "j", defaultJobs, "number of files copied in parallel")
+		s.BoolVar(&c.isVerify, "verify", false, "verify checksums of copied files and retry on a mismatch")
+		s.BoolVar(&c.isResume, "resume", false, "record copied files and skip files completed by the previous run with -resume. Give it on the first run too because copies without -resume are not recorded")
+		c.filter.SetFlags(s)
 		c.flagSet = s
 	}
 	return c.flagSet
@@ -47,42 +73,125 @@ func (c *cp) Reset() {
 	c.isRecursive = false
 	c.isForce = false
 	c.isDryRun = false
+	c.isResume = false
+	c.isVerify = false
+	c.jobs = defaultJobs
+	c.journal = nil
+	c.progress = nil
+	c.stdout = nil
+	c.stderr = nil
+	c.filter.Reset()
+	c.skipped = 0
+	c.resumed = 0
 }
 
-func (c *cp) Exec(sh *fssh.Shell) error {
+func (c *cp) Exec(ctx context.Context, sh *fssh.Shell) error {
 	args := c.FlagSet().Args()
 	if len(args) < 2 {
 		c.Usage(sh.Stderr)
 		return nil
 	}
-	from, to := args[0], args[1]
+	to := args[len(args)-1]
+	var froms []string
+	for _, arg := range args[:len(args)-1] {
+		matches, err := sh.ExpandGlob(arg)
+		if err != nil {
+			return err
+		}
+		froms = append(froms, matches...)
+	}
+	toFS, toName, err := sh.SubFS(to)
+	if err != nil {
+		return err
+	}
+	if len(froms) > 1 {
+		// NOTE: Multiple sources are copied into the directory like cp(1).
+		toInfo, err := fs.Stat(toFS, toName)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err != nil || !toInfo.IsDir() {
+			return fmt.Errorf("%s is not a directory", to)
+		}
+	}
+	if !c.isDryRun {
+		c.progress = sh.NewProgress()
+		defer c.progress.Stop()
+	}
+	c.setOutput(sh)
+	var errs []error
+	for _, from := range froms {
+		if err := c.copy(ctx, sh, from, to, toFS, toName); err != nil {
+			errs = append(errs, err)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// copy copies the from to the toName. The to is the URL of the toName to open the journal.
+func (c *cp) copy(ctx context.Context, sh *fssh.Shell, from, to string, toFS fssh.FS, toName string) error {
 	fromFS, fromName, err := sh.SubFS(from)
 	if err != nil {
 		return err
 	}
-	toFS, toName, err := sh.SubDirFS(to)
+	fromInfo, err := fs.Stat(fromFS, fromName)
 	if err != nil {
-		if os.IsNotExist(err) {
-			toFS, toName, err = sh.SubFS(path.Dir(to))
-			if err != nil {
-				return err
-			}
-			toName = path.Join(toName, path.Base(to))
-		} else {
+		return err
+	}
+	if !fromInfo.IsDir() {
+		c.progress.AddTotal(1, fromInfo.Size())
+		return c.copyFile(ctx, sh, fromFS, toFS, fromName, toName)
+	}
+	if c.isRecursive && c.isResume && !c.isDryRun {
+		if err := c.openJournal(sh, from, to); err != nil {
 			return err
 		}
 	}
-	fromInfo, err := fs.Stat(fromFS, fromName)
+	err = c.copyDir(ctx, sh, fromFS, toFS, fromName, toName)
+	if c.journal != nil {
+		err = c.closeJournal(sh, err)
+	}
+	return err
+}
+
+// setOutput sets the stdout and stderr that workers write to concurrently.
+func (c *cp) setOutput(sh *fssh.Shell) {
+	c.stdout = fssh.NewSyncWriter(sh.Stdout)
+	c.stderr = fssh.NewSyncWriter(sh.Stderr)
+}
+
+// openJournal opens the journal that records copied files to resume the interrupted copy.
+func (c *cp) openJournal(sh *fssh.Shell, from, to string) error {
+	fromUrl, err := sh.AbsURL(from)
 	if err != nil {
 		return err
 	}
-	if fromInfo.IsDir() {
-		return c.copyDir(sh, fromFS, toFS, fromName, toName)
+	toUrl, err := sh.AbsURL(to)
+	if err != nil {
+		return err
 	}
-	return c.copyFile(sh, fromFS, toFS, fromName, toName)
+	c.journal, err = fssh.OpenJournal(fromUrl, toUrl, c.isResume)
+	return err
 }
 
-func (c *cp) copyDir(sh *fssh.Shell, fromFS, toFS fssh.FS, fromName, toName string) error {
+// closeJournal removes the journal if the copy completed otherwise keeps it for resuming.
+func (c *cp) closeJournal(sh *fssh.Shell, err error) error {
+	if c.resumed > 0 {
+		c.progress.Printf(c.stderr, "resumed: %d files were copied by the previous run\n", c.resumed)
+	}
+	journal := c.journal
+	c.journal = nil
+	c.resumed = 0
+	if err != nil {
+		return errors.Join(err, journal.Close())
+	}
+	return journal.Remove()
+}
+
+func (c *cp) copyDir(ctx context.Context, sh *fssh.Shell, fromFS, toFS fssh.FS, fromName, toName string) error {
 	if !c.isRecursive {
 		if c.isForce {
 			return nil
@@ -90,36 +199,73 @@ func (c *cp) copyDir(sh *fssh.Shell, fromFS, toFS fssh.FS, fromName, toName stri
 		return fmt.Errorf("%s is a directory (not copied)", fromName)
 	}
 	toInfo, err := fs.Stat(toFS, toName)
-	if err != nil && !os.IsNotExist(err) {
-		return err
-	}
-	if toInfo != nil && !toInfo.IsDir() {
-		if c.isForce {
-			return nil
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+	} else {
+		if !toInfo.IsDir() {
+			if c.isForce {
+				return nil
+			}
+			return fmt.Errorf("%s is not a directory (not copied)", toName)
+		}
+		// NOTE: The previous run may have created toName so resume into it.
+		intoName := path.Join(toName, path.Base(fromName))
+		if c.journal == nil || !c.isResume || c.journal.HasDir(intoName) || !c.journal.HasDir(toName) {
+			toName = intoName
 		}
-		return fmt.Errorf("%s is not a directory (not copied)", toName)
-	}
-	offset := 0
-	if toInfo == nil {
-		offset = len(path.Base(fromName))
 	}
-	return fs.WalkDir(fromFS, fromName, func(fromPath string, d fs.DirEntry, err error) error {
+	return c.copyTree(ctx, sh, fromFS, toFS, fromName, toName)
+}
+
+// copyTree copies all files in fromName to toName using c.jobs workers.
+// Files are listed before copying so that the progress knows the total.
+func (c *cp) copyTree(ctx context.Context, sh *fssh.Shell, fromFS, toFS fssh.FS, fromName, toName string) error {
+	var fromPaths, toPaths []string
+	err := fs.WalkDir(fromFS, fromName, c.filter.WalkDirFunc(fromName, func(fromPath string, d fs.DirEntry, err error) error {
 		if err != nil || d == nil {
 			return err
 		}
-		toPath := path.Join(toName, strings.TrimLeft(fromPath[offset:], "/"))
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		toPath := path.Join(toName, relPath(fromName, fromPath))
 		if d.IsDir() {
 			if c.isDryRun {
-				fmt.Fprintf(sh.Stdout, "dry-run: mkdir %s\n", toPath)
+				c.progress.Printf(c.stdout, "dry-run: mkdir %s\n", toPath)
 				return nil
 			}
 			return toFS.MkdirAll(toPath, os.ModePerm)
 		}
-		return c.copyFile(sh, fromFS, toFS, fromPath, toPath)
-	})
+		if c.progress != nil {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			c.progress.AddTotal(1, info.Size())
+		}
+		fromPaths = append(fromPaths, fromPath)
+		toPaths = append(toPaths, toPath)
+		return nil
+	}))
+	if err != nil {
+		return err
+	}
+	g := fssh.NewTaskGroup(ctx, c.jobs)
+	for i := range fromPaths {
+		fromPath, toPath := fromPaths[i], toPaths[i]
+		started := g.Go(func(ctx context.Context) error {
+			return c.copyFile(ctx, sh, fromFS, toFS, fromPath, toPath)
+		})
+		if !started {
+			break
+		}
+	}
+	return g.Wait()
 }
 
-func (c *cp) copyFile(sh *fssh.Shell, fromFS, toFS fssh.FS, fromName, toName string) error {
+func (c *cp) copyFile(ctx context.Context, sh *fssh.Shell, fromFS, toFS fssh.FS, fromName, toName string) error {
 	fromInfo, err := fs.Stat(fromFS, fromName)
 	if err != nil {
 		return err
@@ -132,32 +278,141 @@ func (c *cp) copyFile(sh *fssh.Shell, fromFS, toFS fssh.FS, fromName, toName str
 	} else {
 		if toInfo.IsDir() {
 			toName = path.Join(toName, path.Base(fromName))
-		} else if !c.isForce {
-			fmt.Fprintf(sh.Stderr, "skip copying %s because %s exists\n", fromName, toName)
+		} else if c.isCompleted(toFS, toName, fromInfo, toInfo) {
+			c.progress.SkipFile(fromInfo.Size())
+			c.mutex.Lock()
+			c.resumed++
+			c.mutex.Unlock()
+			return nil
+		} else if !c.isForce && !c.isResume {
+			c.progress.Printf(c.stderr, "skip copying %s because %s exists\n", fromName, toName)
+			c.progress.SkipFile(fromInfo.Size())
+			c.mutex.Lock()
+			c.skipped++
+			c.mutex.Unlock()
 			return nil
 		}
 	}
 	if c.isDryRun {
-		fmt.Fprintf(sh.Stdout, "dry-run: copy %s to %s\n", fromName, toName)
+		c.progress.Printf(c.stdout, "dry-run: copy %s to %s\n", fromName, toName)
 		return nil
 	}
 
+	if c.journal == nil && !c.isVerify {
+		err = copyContent(ctx, fromFS, toFS, fromName, toName, fromInfo.Mode(), c.progress)
+	} else {
+		err = c.copyAndRecord(ctx, sh, fromFS, toFS, fromName, toName, fromInfo)
+	}
+	if err != nil {
+		return err
+	}
+	c.progress.DoneFile()
+	return nil
+}
+
+// copyAndRecord copies the file and records it to the journal if the journal is opened.
+func (c *cp) copyAndRecord(ctx context.Context, sh *fssh.Shell, fromFS, toFS fssh.FS, fromName, toName string, fromInfo fs.FileInfo) error {
+	sum, err := c.copyAndVerify(ctx, sh, fromFS, toFS, fromName, toName, fromInfo)
+	if err != nil || c.journal == nil {
+		return err
+	}
+	return c.journal.Record(fssh.NewJournalEntry(toName, fromInfo.Size(), sum))
+}
+
+// copyAndVerify copies the file computing the checksum. If c.isVerify is true then this compares
+// the checksum with the destination and copies again on a mismatch up to verifyAttempts times.
+func (c *cp) copyAndVerify(ctx context.Context, sh *fssh.Shell, fromFS, toFS fssh.FS, fromName, toName string, fromInfo fs.FileInfo) (*fssh.Checksum, error) {
+	w := fssh.NewChecksumWriter()
+	for attempt := 1; ; attempt++ {
+		w.Reset()
+		if err := copyContent(ctx, fromFS, toFS, fromName, toName, fromInfo.Mode(), w, c.progress); err != nil {
+			return nil, err
+		}
+		sum := w.Checksum()
+		if !c.isVerify {
+			return sum, nil
+		}
+		err := fssh.VerifyChecksum(toFS, toName, sum)
+		if err == nil {
+			return sum, nil
+		}
+		if !errors.Is(err, fssh.ErrChecksumMismatch) || attempt >= verifyAttempts {
+			return nil, err
+		}
+		c.progress.Printf(c.stderr, "retry copying %s: %v\n", fromName, err)
+		c.progress.AddTotal(0, fromInfo.Size())
+	}
+}
+
+// isCompleted reports whether the file was completely copied by the previous run.
+// The destination is compared with the checksum recorded in the journal.
+func (c *cp) isCompleted(toFS fssh.FS, toName string, fromInfo, toInfo fs.FileInfo) bool {
+	if !c.isResume || c.journal == nil {
+		return false
+	}
+	e, ok := c.journal.Lookup(toName)
+	if !ok || e.Size != fromInfo.Size() || e.Size != toInfo.Size() {
+		return false
+	}
+	sum, err := e.Checksum()
+	if err != nil {
+		return false
+	}
+	return fssh.VerifyChecksum(toFS, toName, sum) == nil
+}
+
+// copyContent copies the content of the file. The content is also written to ws such as checksums and progress.
+// The error of closing the destination is returned because object storages upload the content on Close.
+// If the ctx is cancelled then this stops copying and removes the partially copied file.
+func copyContent(ctx context.Context, fromFS, toFS fssh.FS, fromName, toName string, mode fs.FileMode, ws ...io.Writer) (err error) {
 	fromFile, err := fromFS.Open(fromName)
 	if err != nil {
 		return err
 	}
 	defer fromFile.Close()
 
-	toFile, err := toFS.CreateFile(toName, fromInfo.Mode())
+	var r io.Reader = &contextReader{ctx: ctx, r: fromFile}
+	if len(ws) > 0 {
+		r = io.TeeReader(r, io.MultiWriter(ws...))
+	}
+
+	toFile, err := toFS.CreateFile(toName, mode)
 	if err != nil {
 		return err
 	}
-	defer toFile.Close()
+	defer func() {
+		if e := toFile.Close(); e != nil && err == nil {
+			err = e
+		}
+		if ctx.Err() != nil && err != nil {
+			// NOTE: Object storages upload the partial content on Close so remove it.
+			_ = wfs.RemoveFile(toFS, toName)
+		}
+	}()
 
-	if _, err := io.Copy(toFile, fromFile); err != nil {
-		return err
+	_, err = io.Copy(toFile, r)
+	return err
+}
+
+// contextReader returns the error of the ctx when the ctx is cancelled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
 	}
-	return nil
+	return r.r.Read(p)
+}
+
+// relPath returns the path of name relative to the dir. The name must be in the dir.
+func relPath(dir, name string) string {
+	if dir == "." {
+		return name
+	}
+	return strings.TrimLeft(strings.TrimPrefix(name, dir), "/")
 }
 
 func (c *cp) AutoCompleter() fssh.AutoCompleterFunc {
@@ -173,14 +428,19 @@ func (c *cp) autoComplete(sh *fssh.Shell, arg string) ([]string, error) {
 
 func (c *cp) Usage(w io.Writer) {
 	name := c.Name()
-	fmt.Fprintf(w, "Usage:\n  %s ([flags]) [from] [to]\n", name)
+	fmt.Fprintf(w, "Usage:\n  %s ([flags]) [from]... [to]\n", name)
 	fmt.Fprintln(w, "Flags:")
 	c.FlagSet().SetOutput(w)
 	c.FlagSet().PrintDefaults()
 	fmt.Fprintln(w, "Examples:")
 	fmt.Fprintf(w, "  %s FROM TO\n", name)
 	fmt.Fprintf(w, "  %s LOCAL_FILE (s3|gs)://BUCKET/DIR\n", name)
+	fmt.Fprintf(w, "  %s FILE1 FILE2 '*.csv' (s3|gs)://BUCKET/DIR/\n", name)
 	fmt.Fprintf(w, "  %s -rf (s3|gs)://BUCKET/DIR LOCAL_DIR\n", name)
+	fmt.Fprintf(w, "  %s -r -j 16 s3://BUCKET/DIR gs://BUCKET/DIR\n", name)
+	fmt.Fprintf(w, "  %s -r -exclude '*.tmp' -exclude node_modules/ LOCAL_DIR (s3|gs)://BUCKET/DIR\n", name)
+	fmt.Fprintf(w, "  %s -verify LOCAL_FILE (s3|gs)://BUCKET/DIR\n", name)
+	fmt.Fprintf(w, "  %s -r -resume s3://BUCKET/DIR gs://BUCKET/DIR # Run again to continue if interrupted\n", name)
 }
 
 func init() {
@@ -0,0 +1,220 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/jarxorg/fssh"
+)
+
+func TestCp(t *testing.T) {
+	tests := []struct {
+		files  map[string]string
+		line   string
+		want   map[string]string
+		errstr string
+	}{
+		{
+			files: map[string]string{"a.txt": "a"},
+			line:  "cp a.txt b.txt",
+			want:  map[string]string{"a.txt": "a", "b.txt": "a"},
+		}, {
+			files: map[string]string{"a.txt": "a", "b.txt": "b"},
+			line:  "cp a.txt b.txt",
+			want:  map[string]string{"a.txt": "a", "b.txt": "b"},
+		}, {
+			files: map[string]string{"a.txt": "a", "b.txt": "b"},
+			line:  "cp -f a.txt b.txt",
+			want:  map[string]string{"a.txt": "a", "b.txt": "a"},
+		}, {
+			files: map[string]string{"a.txt": "a", "dir/": ""},
+			line:  "cp a.txt dir",
+			want:  map[string]string{"a.txt": "a", "dir/a.txt": "a"},
+		}, {
+			files: map[string]string{"a/b/dir/c.txt": "c"},
+			line:  "cp -r a/b/dir dst",
+			want:  map[string]string{"a/b/dir/c.txt": "c", "dst/c.txt": "c"},
+		}, {
+			files: map[string]string{"a/b/dir/c.txt": "c", "dst/": ""},
+			line:  "cp -r a/b/dir dst",
+			want:  map[string]string{"a/b/dir/c.txt": "c", "dst/dir/c.txt": "c"},
+		}, {
+			files:  map[string]string{"dir/c.txt": "c", "dst": "dst"},
+			line:   "cp -r dir dst",
+			want:   map[string]string{"dir/c.txt": "c", "dst": "dst"},
+			errstr: "dst is not a directory (not copied)",
+		}, {
+			files:  map[string]string{"dir/c.txt": "c"},
+			line:   "cp dir dst",
+			want:   map[string]string{"dir/c.txt": "c"},
+			errstr: "dir is a directory (not copied)",
+		}, {
+			files:  map[string]string{"a.txt": "a", "b.txt": "b", "dst": "dst"},
+			line:   "cp a.txt b.txt dst",
+			want:   map[string]string{"a.txt": "a", "b.txt": "b", "dst": "dst"},
+			errstr: "dst is not a directory",
+		}, {
+			files: map[string]string{"a.txt": "a", "b.csv": "b", "c.csv": "c", "dst/": ""},
+			line:  "cp '*.csv' dst",
+			want:  map[string]string{"a.txt": "a", "b.csv": "b", "c.csv": "c", "dst/b.csv": "b", "dst/c.csv": "c"},
+		},
+	}
+	for i, test := range tests {
+		setTestHome(t, nil)
+		sh, _, _ := newTestShell(t, "mem://", test.files)
+
+		err := execTestLine(sh, test.line)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+		} else if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if got := readTestFiles(t, sh.FS, sh.Dir); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d]: got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestCpFilter(t *testing.T) {
+	files := map[string]string{
+		"d/a.csv":     "a",
+		"d/b.txt":     "b",
+		"d/sub/c.csv": "c",
+		"d/sub/d.txt": "d",
+	}
+	tests := []struct {
+		line string
+		want map[string]string
+	}{
+		{
+			line: "cp -r -include '*.csv' d dst",
+			want: map[string]string{"a.csv": "a", "sub/c.csv": "c"},
+		}, {
+			line: "cp -r -exclude '*.csv' d dst",
+			want: map[string]string{"b.txt": "b", "sub/d.txt": "d"},
+		}, {
+			line: "cp -r -exclude sub/ d dst",
+			want: map[string]string{"a.csv": "a", "b.txt": "b"},
+		}, {
+			line: "cp -r -exclude '*' -include '*.csv' d dst",
+			want: map[string]string{"a.csv": "a", "sub/c.csv": "c"},
+		}, {
+			line: "cp -r -exclude '*' -include 'sub/*.txt' d dst",
+			want: map[string]string{"sub/d.txt": "d"},
+		},
+	}
+	for i, test := range tests {
+		setTestHome(t, nil)
+		sh, _, _ := newTestShell(t, "mem://", files)
+
+		if err := execTestLine(sh, test.line); err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if got := readTestFiles(t, sh.FS, "dst"); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d]: got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestCpJobs(t *testing.T) {
+	files := map[string]string{}
+	var wantDryRun []string
+	for i := 0; i < 100; i++ {
+		name := fmt.Sprintf("%03d.txt", i)
+		files["d/"+name] = name
+		files["dst/d/"+name] = "exists"
+		wantDryRun = append(wantDryRun, "dry-run: copy d/"+name+" to dst/d/"+name)
+	}
+	wantDryRun = append(wantDryRun, "dry-run: mkdir dst/d")
+	sort.Strings(wantDryRun)
+
+	setTestHome(t, nil)
+	sh, stdout, stderr := newTestShell(t, "mem://", files)
+	if err := execTestLine(sh, "cp -r -f -d -j 8 d dst"); err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, wantDryRun) {
+		t.Errorf("got %q; want %q", got, wantDryRun)
+	}
+
+	if err := execTestLine(sh, "cp -r -j 8 d dst"); err != nil {
+		t.Fatal(err)
+	}
+	got = strings.Split(strings.TrimSuffix(stderr.String(), "\n"), "\n")
+	if len(got) != 100 {
+		t.Errorf("got %d lines; want %d", len(got), 100)
+	}
+	for i, line := range got {
+		if !strings.HasPrefix(line, "skip copying d/") {
+			t.Errorf("lines[%d]: got %q", i, line)
+		}
+	}
+}
+
+func TestCpResume(t *testing.T) {
+	homeFS, homeDir := setTestHome(t, nil)
+	sh, _, stderr := newTestShell(t, "mem://", map[string]string{
+		"d/a.txt":   "a",
+		"d/b.txt":   "b",
+		"d/c.txt":   "c",
+		"dst/a.txt": "a",
+		"dst/b.txt": "x",
+	})
+
+	if err := execTestLine(sh, "cp -r d copied"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFiles(t, homeFS, homeDir); len(got) != 0 {
+		t.Errorf("got %v; want no journal without -resume", got)
+	}
+
+	fromUrl, err := sh.AbsURL("d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	toUrl, err := sh.AbsURL("dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, err := fssh.OpenJournal(fromUrl, toUrl, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// NOTE: dst/b.txt was recorded but the content was changed after that.
+	for _, name := range []string{"a.txt", "b.txt"} {
+		w := fssh.NewChecksumWriter()
+		w.Write([]byte(name[:1]))
+		if err := j.Record(fssh.NewJournalEntry("dst/"+name, 1, w.Checksum())); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := execTestLine(sh, "cp -r -resume d dst"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c"}
+	if got := readTestFiles(t, sh.FS, "dst"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+	if got, want := stderr.String(), "resumed: 1 files were copied by the previous run\n"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	path, err := fssh.JournalPath(fromUrl, toUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("got err %v; want the journal removed", err)
+	}
+}
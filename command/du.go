@@ -0,0 +1,247 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+
+	"github.com/jarxorg/fssh"
+)
+
+// defaultDuJobs is the default number of directories listed in parallel.
+const defaultDuJobs = 16
+
+type du struct {
+	flagSet     *flag.FlagSet
+	isSummarize bool
+	isHuman     bool
+	depth       int
+	jobs        int
+	format      fssh.Format
+	formatter   *fssh.Formatter
+}
+
+// duDir represents the usage of a directory.
+type duDir struct {
+	name     string
+	size     int64
+	count    int64
+	children []*duDir
+	err      error
+}
+
+// duRecord represents the usage of a file or a directory in machine readable output.
+type duRecord struct {
+	URL   string `json:"url"`
+	Size  int64  `json:"size"`
+	Files int64  `json:"files"`
+}
+
+var _ fssh.Record = (*duRecord)(nil)
+
+func (r *duRecord) CSVHeader() []string {
+	return []string{"url", "size", "files"}
+}
+
+func (r *duRecord) CSVRecord() []string {
+	return []string{r.URL, strconv.FormatInt(r.Size, 10), strconv.FormatInt(r.Files, 10)}
+}
+
+func newDu() fssh.Command {
+	return &du{}
+}
+
+func (c *du) Name() string {
+	return "du"
+}
+
+func (c *du) Description() string {
+	return "display disk usage statistics"
+}
+
+func (c *du) FlagSet() *flag.FlagSet {
+	if c.flagSet == nil {
+		s := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+		s.Usage = func() {}
+		s.BoolVar(&c.isSummarize, "s", false, "display only a total for each argument")
+		s.BoolVar(&c.isHuman, "h", false, "human-readable sizes")
+		s.IntVar(&c.depth, "d", -1, "display directories at most n levels deep")
+		s.IntVar(&c.jobs, "j", defaultDuJobs, "number of directories listed in parallel")
+		c.format.SetFlags(s)
+		c.flagSet = s
+	}
+	return c.flagSet
+}
+
+func (c *du) Reset() {
+	c.isSummarize = false
+	c.isHuman = false
+	c.depth = -1
+	c.jobs = defaultDuJobs
+	c.format = ""
+	c.formatter = nil
+}
+
+func (c *du) Exec(ctx context.Context, sh *fssh.Shell) error {
+	args := c.FlagSet().Args()
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	if c.isSummarize {
+		c.depth = 0
+	}
+	c.formatter = sh.NewFormatter(c.format)
+	var errs []error
+	for _, arg := range args {
+		if err := c.du(ctx, sh, arg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(append(errs, c.formatter.Close())...)
+}
+
+func (c *du) du(ctx context.Context, sh *fssh.Shell, nameUrl string) error {
+	fsys, name, err := sh.SubFS(nameUrl)
+	if err != nil {
+		return err
+	}
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return c.print(sh, info.Size(), 1, nameUrl)
+	}
+	root := &duDir{name: name}
+	if err := c.walk(ctx, fsys, root); err != nil {
+		return err
+	}
+
+	var errs []error
+	c.sum(root, &errs)
+	if err := c.printDir(sh, root, nameUrl, name, 0); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// walk lists the directory and its subdirectories level by level using c.jobs workers.
+// Errors of directories are kept in the directories and the error of the ctx is returned.
+func (c *du) walk(ctx context.Context, fsys fssh.FS, root *duDir) error {
+	dirs := []*duDir{root}
+	for len(dirs) > 0 {
+		g := fssh.NewTaskGroup(ctx, c.jobs)
+		for _, d := range dirs {
+			d := d
+			if !g.Go(func(ctx context.Context) error {
+				c.list(fsys, d)
+				return nil
+			}) {
+				break
+			}
+		}
+		if err := g.Wait(); err != nil {
+			return err
+		}
+		var children []*duDir
+		for _, d := range dirs {
+			children = append(children, d.children...)
+		}
+		dirs = children
+	}
+	return nil
+}
+
+// list sums sizes and counts of files in the directory and adds its subdirectories to the children.
+func (c *du) list(fsys fssh.FS, d *duDir) {
+	entries, err := fs.ReadDir(fsys, d.name)
+	if err != nil {
+		d.err = err
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			d.children = append(d.children, &duDir{name: path.Join(d.name, entry.Name())})
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			d.err = err
+			return
+		}
+		d.size += info.Size()
+		d.count++
+	}
+}
+
+// sum adds sizes and counts of subdirectories to the directory.
+func (c *du) sum(d *duDir, errs *[]error) {
+	if d.err != nil {
+		*errs = append(*errs, d.err)
+	}
+	for _, child := range d.children {
+		c.sum(child, errs)
+		d.size += child.size
+		d.count += child.count
+	}
+}
+
+// printDir prints subdirectories before the directory like du(1).
+func (c *du) printDir(sh *fssh.Shell, d *duDir, rootUrl, rootName string, depth int) error {
+	if c.depth < 0 || depth < c.depth {
+		for _, child := range d.children {
+			if err := c.printDir(sh, child, rootUrl, rootName, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return c.print(sh, d.size, d.count, fssh.JoinURL(rootUrl, relPath(rootName, d.name)))
+}
+
+func (c *du) print(sh *fssh.Shell, size, count int64, nameUrl string) error {
+	if !c.formatter.IsText() {
+		url, err := sh.AbsURL(nameUrl)
+		if err != nil {
+			return err
+		}
+		return c.formatter.Write(&duRecord{URL: url, Size: size, Files: count})
+	}
+	if c.isHuman {
+		fmt.Fprintf(sh.Stdout, "%s\t%d\t%s\n", fssh.DisplaySize(size), count, nameUrl)
+		return nil
+	}
+	fmt.Fprintf(sh.Stdout, "%d\t%d\t%s\n", size, count, nameUrl)
+	return nil
+}
+
+func (c *du) AutoCompleter() fssh.AutoCompleterFunc {
+	return c.autoComplete
+}
+
+func (c *du) autoComplete(sh *fssh.Shell, arg string) ([]string, error) {
+	return sh.PrefixMatcher.Matches(sh, arg)
+}
+
+func (c *du) Usage(w io.Writer) {
+	name := c.Name()
+	fmt.Fprintf(w, "Usage:\n  %s ([flags]) ([dir]...)\n", name)
+	fmt.Fprintln(w, "Flags:")
+	c.FlagSet().SetOutput(w)
+	c.FlagSet().PrintDefaults()
+	fmt.Fprintln(w, "Output:")
+	fmt.Fprintln(w, "  SIZE\tFILES\tURL")
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintf(w, "  %s DIR\n", name)
+	fmt.Fprintf(w, "  %s -s -h (s3|gs)://BUCKET/DIR\n", name)
+	fmt.Fprintf(w, "  %s -d 1 (s3|gs)://BUCKET\n", name)
+	fmt.Fprintf(w, "  %s -s -j 64 (s3|gs)://BUCKET\n", name)
+}
+
+func init() {
+	fssh.RegisterNewCommandFunc(newDu)
+}
@@ -0,0 +1,51 @@
+package command
+
+import "testing"
+
+func TestDu(t *testing.T) {
+	files := map[string]string{
+		"a.txt":         "a",
+		"d/b.txt":       "bb",
+		"d/sub/c.txt":   "ccc",
+		"d/sub/e/d.txt": "dddd",
+		"d/empty/":      "",
+	}
+	tests := []struct {
+		line   string
+		want   string
+		errstr string
+	}{
+		{
+			line: "du d",
+			want: "0\t0\td/empty\n4\t1\td/sub/e\n7\t2\td/sub\n9\t3\td\n",
+		}, {
+			line: "du -s d a.txt",
+			want: "9\t3\td\n1\t1\ta.txt\n",
+		}, {
+			line: "du -d 1 -j 1 d",
+			want: "0\t0\td/empty\n7\t2\td/sub\n9\t3\td\n",
+		}, {
+			line: "du -s -j 1",
+			want: "10\t4\t.\n",
+		}, {
+			line:   "du missing",
+			errstr: "Open missing: file does not exist",
+		},
+	}
+	for i, test := range tests {
+		setTestHome(t, nil)
+		sh, stdout, _ := newTestShell(t, "mem://", files)
+
+		err := execTestLine(sh, test.line)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+		} else if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if got := stdout.String(); got != test.want {
+			t.Errorf("tests[%d]: got %q; want %q", i, got, test.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package command
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
@@ -38,7 +39,7 @@ func (c *env) FlagSet() *flag.FlagSet {
 func (c *env) Reset() {
 }
 
-func (c *env) Exec(sh *fssh.Shell) error {
+func (c *env) Exec(ctx context.Context, sh *fssh.Shell) error {
 	args := c.FlagSet().Args()
 	if len(args) == 0 {
 		for _, e := range os.Environ() {
@@ -53,12 +54,12 @@ func (c *env) Exec(sh *fssh.Shell) error {
 			set++
 			os.Setenv(kv[0], kv[1])
 		} else {
-			fmt.Fprintf(os.Stdout, "%s=%s\n", arg, os.Getenv(arg))
+			fmt.Fprintf(sh.Stdout, "%s=%s\n", arg, os.Getenv(arg))
 		}
 	}
 	if set > 0 {
 		// NOTE: Re-create FS for apply environments.
-		fsys, _, _, _, err := fssh.NewFS(sh.Host)
+		fsys, _, _, _, err := sh.NewFS(sh.Host)
 		if err != nil {
 			return nil
 		}
@@ -1,6 +1,7 @@
 package command
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
@@ -36,7 +37,7 @@ func (c *exit) FlagSet() *flag.FlagSet {
 func (c *exit) Reset() {
 }
 
-func (c *exit) Exec(sh *fssh.Shell) error {
+func (c *exit) Exec(ctx context.Context, sh *fssh.Shell) error {
 	return fssh.ErrExit
 }
 
@@ -0,0 +1,311 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jarxorg/fssh"
+	"github.com/jarxorg/wfs"
+)
+
+type find struct {
+	flagSet  *flag.FlagSet
+	name     string
+	typ      string
+	size     string
+	mtime    string
+	maxDepth int
+	isPrint0 bool
+	isDelete bool
+	isExec   bool
+	format   fssh.Format
+}
+
+func newFind() fssh.Command {
+	return &find{}
+}
+
+func (c *find) Name() string {
+	return "find"
+}
+
+func (c *find) Description() string {
+	return "walk file hierarchies"
+}
+
+func (c *find) FlagSet() *flag.FlagSet {
+	if c.flagSet == nil {
+		s := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+		s.Usage = func() {}
+		s.StringVar(&c.name, "name", "", "base name matches the pattern")
+		s.StringVar(&c.typ, "type", "", "file type is f (regular file) or d (directory)")
+		s.StringVar(&c.size, "size", "", "size is n[ckMG] (+n: greater than, -n: less than, no suffix: 512-byte blocks)")
+		s.StringVar(&c.mtime, "mtime", "", "modified n days ago (+n: more than, -n: less than)")
+		s.IntVar(&c.maxDepth, "maxdepth", -1, "descend at most n directory levels")
+		s.BoolVar(&c.isPrint0, "print0", false, "print names followed by a null character")
+		s.BoolVar(&c.isDelete, "delete", false, "delete found files")
+		s.BoolVar(&c.isExec, "exec", false, "execute a fssh command terminated by \";\" ({} is replaced with the found name)")
+		c.format.SetFlags(s)
+		c.flagSet = s
+	}
+	return c.flagSet
+}
+
+func (c *find) Reset() {
+	c.name = ""
+	c.typ = ""
+	c.size = ""
+	c.mtime = ""
+	c.maxDepth = -1
+	c.isPrint0 = false
+	c.isDelete = false
+	c.isExec = false
+	c.format = ""
+}
+
+// findPredicate reports whether the file matches.
+type findPredicate func(info fs.FileInfo) bool
+
+func (c *find) Exec(ctx context.Context, sh *fssh.Shell) error {
+	args := c.FlagSet().Args()
+	root := "."
+	if len(args) > 0 && !c.isExec && !strings.HasPrefix(args[0], "-") {
+		// NOTE: Parse flags after the root because flag stops parsing at the first non-flag argument.
+		root = args[0]
+		if err := c.FlagSet().Parse(args[1:]); err != nil {
+			return err
+		}
+		args = c.FlagSet().Args()
+	}
+	var execArgs []string
+	if c.isExec {
+		for _, arg := range args {
+			if arg == ";" {
+				break
+			}
+			execArgs = append(execArgs, arg)
+		}
+		if len(execArgs) == 0 {
+			return fmt.Errorf("-exec: missing command")
+		}
+	} else if len(args) > 0 {
+		return fmt.Errorf("unknown argument: %s", args[0])
+	}
+	preds, err := c.predicates(time.Now())
+	if err != nil {
+		return err
+	}
+
+	fsys, rootName, err := sh.SubFS(root)
+	if err != nil {
+		return err
+	}
+	f := sh.NewFormatter(c.format)
+	var founds []string
+	err = fs.WalkDir(fsys, rootName, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d == nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel := relPath(rootName, name)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if c.matches(preds, info) {
+			switch {
+			case c.isDelete:
+				founds = append(founds, name)
+			case c.isExec:
+				if err := c.exec(ctx, sh, execArgs, fssh.JoinURL(root, rel)); err != nil {
+					return err
+				}
+			case !f.IsText():
+				url, err := sh.AbsURL(fssh.JoinURL(root, rel))
+				if err != nil {
+					return err
+				}
+				if err := f.Write(fssh.NewFileRecord(url, info)); err != nil {
+					return err
+				}
+			default:
+				c.print(sh, fssh.JoinURL(root, rel))
+			}
+		}
+		if c.maxDepth >= 0 && d.IsDir() && depth(rel) >= c.maxDepth {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err := errors.Join(err, f.Close()); err != nil {
+		return err
+	}
+	// NOTE: Delete files before the parent directories.
+	for i := len(founds) - 1; i >= 0; i-- {
+		if err := wfs.RemoveFile(fsys, founds[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *find) matches(preds []findPredicate, info fs.FileInfo) bool {
+	for _, pred := range preds {
+		if !pred(info) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *find) predicates(now time.Time) ([]findPredicate, error) {
+	var preds []findPredicate
+	if c.name != "" {
+		if _, err := path.Match(c.name, ""); err != nil {
+			return nil, fmt.Errorf("-name: %w", err)
+		}
+		pattern := c.name
+		preds = append(preds, func(info fs.FileInfo) bool {
+			ok, _ := path.Match(pattern, info.Name())
+			return ok
+		})
+	}
+	switch c.typ {
+	case "":
+	case "f":
+		preds = append(preds, func(info fs.FileInfo) bool {
+			return info.Mode().IsRegular()
+		})
+	case "d":
+		preds = append(preds, func(info fs.FileInfo) bool {
+			return info.IsDir()
+		})
+	default:
+		return nil, fmt.Errorf("-type: unknown type %s", c.typ)
+	}
+	if c.size != "" {
+		cmp, n, unit, err := parseFindSize(c.size)
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, func(info fs.FileInfo) bool {
+			// NOTE: Sizes are rounded up to the unit like find(1).
+			return compareFindNumber(cmp, (info.Size()+unit-1)/unit, n)
+		})
+	}
+	if c.mtime != "" {
+		cmp, n, err := parseFindNumber(c.mtime)
+		if err != nil {
+			return nil, fmt.Errorf("-mtime: %w", err)
+		}
+		preds = append(preds, func(info fs.FileInfo) bool {
+			days := int64(now.Sub(info.ModTime()) / (24 * time.Hour))
+			return compareFindNumber(cmp, days, n)
+		})
+	}
+	return preds, nil
+}
+
+func (c *find) exec(ctx context.Context, sh *fssh.Shell, execArgs []string, nameUrl string) error {
+	args := make([]string, len(execArgs))
+	for i, arg := range execArgs {
+		args[i] = strings.ReplaceAll(arg, "{}", nameUrl)
+	}
+	return sh.ExecCommand(ctx, args)
+}
+
+func (c *find) print(sh *fssh.Shell, nameUrl string) {
+	if c.isPrint0 {
+		fmt.Fprintf(sh.Stdout, "%s\x00", nameUrl)
+		return
+	}
+	fmt.Fprintf(sh.Stdout, "%s\n", nameUrl)
+}
+
+// depth returns the depth of the relative path.
+func depth(rel string) int {
+	if rel == "." || rel == "" {
+		return 0
+	}
+	return strings.Count(rel, "/") + 1
+}
+
+// parseFindNumber parses n, +n or -n.
+func parseFindNumber(s string) (cmp byte, n int64, err error) {
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		cmp = s[0]
+		s = s[1:]
+	}
+	n, err = strconv.ParseInt(s, 10, 64)
+	return
+}
+
+// parseFindSize parses n[ckMG] and returns the unit of bytes.
+func parseFindSize(s string) (cmp byte, n int64, unit int64, err error) {
+	unit = 512
+	if s != "" {
+		switch s[len(s)-1] {
+		case 'c':
+			unit = 1
+		case 'k':
+			unit = 1024
+		case 'M':
+			unit = 1024 * 1024
+		case 'G':
+			unit = 1024 * 1024 * 1024
+		}
+		if unit != 512 {
+			s = s[:len(s)-1]
+		}
+	}
+	cmp, n, err = parseFindNumber(s)
+	if err != nil {
+		err = fmt.Errorf("-size: %w", err)
+	}
+	return
+}
+
+func compareFindNumber(cmp byte, v, n int64) bool {
+	switch cmp {
+	case '+':
+		return v > n
+	case '-':
+		return v < n
+	}
+	return v == n
+}
+
+func (c *find) AutoCompleter() fssh.AutoCompleterFunc {
+	return c.autoComplete
+}
+
+func (c *find) autoComplete(sh *fssh.Shell, arg string) ([]string, error) {
+	return sh.PrefixMatcher.MatchDirs(sh, arg)
+}
+
+func (c *find) Usage(w io.Writer) {
+	name := c.Name()
+	fmt.Fprintf(w, "Usage:\n  %s ([dir]) ([flags])\n", name)
+	fmt.Fprintln(w, "Flags:")
+	c.FlagSet().SetOutput(w)
+	c.FlagSet().PrintDefaults()
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintf(w, "  %s DIR -name '*.json' -type f\n", name)
+	fmt.Fprintf(w, "  %s (s3|gs)://BUCKET/DIR -size +10M -mtime -7\n", name)
+	fmt.Fprintf(w, "  %s (s3|gs)://BUCKET/DIR -name '*.tmp' -delete\n", name)
+	fmt.Fprintf(w, "  %s DIR -name '*.csv' -exec cp {} (s3|gs)://BUCKET/DIR/ \\;\n", name)
+}
+
+func init() {
+	fssh.RegisterNewCommandFunc(newFind)
+}
@@ -0,0 +1,104 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	files := map[string]string{
+		"d/a.csv":       "a",
+		"d/b.txt":       "bbbb",
+		"d/sub/c.csv":   "cc",
+		"d/sub/e/d.txt": "",
+	}
+	tests := []struct {
+		line   string
+		want   string
+		errstr string
+	}{
+		{
+			line: "find d",
+			want: "d\nd/a.csv\nd/b.txt\nd/sub\nd/sub/c.csv\nd/sub/e\nd/sub/e/d.txt\n",
+		}, {
+			line: "find d -name '*.csv'",
+			want: "d/a.csv\nd/sub/c.csv\n",
+		}, {
+			line: "find d -type d",
+			want: "d\nd/sub\nd/sub/e\n",
+		}, {
+			line: "find d -type f -maxdepth 1",
+			want: "d/a.csv\nd/b.txt\n",
+		}, {
+			line: "find d -size +1c",
+			want: "d/b.txt\nd/sub/c.csv\n",
+		}, {
+			line: "find d -type f -size -1",
+			want: "d/sub/e/d.txt\n",
+		}, {
+			line: "find d -name '*.csv' -print0",
+			want: "d/a.csv\x00d/sub/c.csv\x00",
+		}, {
+			line: "find d -name '*.csv' -exec cat {} ;",
+			want: "a\ncc\n",
+		}, {
+			line:   "find d -type x",
+			errstr: "-type: unknown type x",
+		}, {
+			line:   "find d -name '['",
+			errstr: "-name: syntax error in pattern",
+		}, {
+			line:   "find d -exec ;",
+			errstr: "-exec: missing command",
+		}, {
+			line:   "find d x",
+			errstr: "unknown argument: x",
+		},
+	}
+	for i, test := range tests {
+		setTestHome(t, nil)
+		sh, stdout, _ := newTestShell(t, "mem://", files)
+
+		err := execTestLine(sh, test.line)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+		} else if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if got := stdout.String(); got != test.want {
+			t.Errorf("tests[%d]: got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestFindDelete(t *testing.T) {
+	setTestHome(t, nil)
+	sh, _, _ := newTestShell(t, "mem://", map[string]string{
+		"d/a.tmp":     "a",
+		"d/b.txt":     "b",
+		"d/sub/c.tmp": "c",
+	})
+	if err := execTestLine(sh, "find d -name '*.tmp' -delete"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"b.txt": "b", "sub/": ""}
+	if got := readTestFiles(t, sh.FS, "d"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestFindMtime(t *testing.T) {
+	setTestHome(t, nil)
+	sh, stdout, _ := newTestShell(t, "", map[string]string{"d/a.txt": "a", "d/b.csv": "b"})
+	if err := execTestLine(sh, "find d -type f -mtime -1 -name '*.txt'"); err != nil {
+		t.Fatal(err)
+	}
+	if err := execTestLine(sh, "find d -mtime +1"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stdout.String(), "d/a.txt\n"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,345 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/jarxorg/fssh"
+)
+
+const (
+	// grepWorkers is the number of files read in parallel.
+	grepWorkers = 8
+	// grepBufferedLines is the number of matched lines of a file buffered until the lines are printed.
+	grepBufferedLines = 64
+)
+
+type grep struct {
+	flagSet          *flag.FlagSet
+	isRecursive      bool
+	isIgnoreCase     bool
+	isLineNumber     bool
+	isFilesWithMatch bool
+	isExtended       bool
+}
+
+// grepFile represents a file to search and its result. The lines is closed after the err is set.
+type grepFile struct {
+	fsys  fssh.FS
+	name  string
+	url   string
+	lines chan []byte
+	err   error
+}
+
+// grepLineWriter sends each written line to the lines. If the ctx is cancelled then Write returns
+// the error of the ctx.
+type grepLineWriter struct {
+	ctx   context.Context
+	lines chan<- []byte
+}
+
+func (w *grepLineWriter) Write(p []byte) (int, error) {
+	select {
+	case w.lines <- bytes.Clone(p):
+		return len(p), nil
+	case <-w.ctx.Done():
+		return 0, w.ctx.Err()
+	}
+}
+
+func newGrep() fssh.Command {
+	return &grep{}
+}
+
+func (c *grep) Name() string {
+	return "grep"
+}
+
+func (c *grep) Description() string {
+	return "file pattern searcher"
+}
+
+func (c *grep) FlagSet() *flag.FlagSet {
+	if c.flagSet == nil {
+		s := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+		s.Usage = func() {}
+		s.BoolVar(&c.isRecursive, "r", false, "search directories recursively")
+		s.BoolVar(&c.isIgnoreCase, "i", false, "ignore case")
+		s.BoolVar(&c.isLineNumber, "n", false, "print line numbers")
+		s.BoolVar(&c.isFilesWithMatch, "l", false, "print only names of files with matches")
+		s.BoolVar(&c.isExtended, "E", false, "interpret the pattern as an extended regular expression")
+		c.flagSet = s
+	}
+	return c.flagSet
+}
+
+func (c *grep) Reset() {
+	c.isRecursive = false
+	c.isIgnoreCase = false
+	c.isLineNumber = false
+	c.isFilesWithMatch = false
+	c.isExtended = false
+}
+
+func (c *grep) Exec(ctx context.Context, sh *fssh.Shell) error {
+	args := c.FlagSet().Args()
+	if len(args) == 0 {
+		c.Usage(sh.Stderr)
+		return nil
+	}
+	re, err := c.compile(args[0])
+	if err != nil {
+		return err
+	}
+	nameUrls := args[1:]
+	if len(nameUrls) == 0 || (len(nameUrls) == 1 && nameUrls[0] == "-") {
+		return c.grep(sh.Stdout, re, &contextReader{ctx: ctx, r: sh.Stdin}, "")
+	}
+	withName := len(nameUrls) > 1 || c.isRecursive
+
+	// NOTE: Workers are cancelled if writing to the stdout fails such as "grep -r ERROR . | head".
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	files := make(chan *grepFile, grepWorkers*2)
+	jobs := make(chan *grepFile)
+	var errs []error
+	var errsMutex sync.Mutex
+	go func() {
+		defer close(files)
+		defer close(jobs)
+		for _, nameUrl := range nameUrls {
+			if err := c.walk(ctx, sh, nameUrl, files, jobs); err != nil {
+				errsMutex.Lock()
+				errs = append(errs, err)
+				errsMutex.Unlock()
+			}
+		}
+	}()
+	wg := &sync.WaitGroup{}
+	for i := 0; i < grepWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range jobs {
+				c.grepFile(ctx, re, f, withName)
+			}
+		}()
+	}
+	// NOTE: Print results in the order of files while workers search the following files.
+	var writeErr error
+	for f := range files {
+		if ctx.Err() != nil {
+			continue
+		}
+		if err := c.printFile(ctx, sh.Stdout, f); err != nil {
+			writeErr = err
+			cancel()
+			continue
+		}
+		if f.err != nil {
+			errsMutex.Lock()
+			errs = append(errs, f.err)
+			errsMutex.Unlock()
+		}
+	}
+	wg.Wait()
+	if writeErr != nil {
+		// NOTE: Other errors are caused by cancelling workers.
+		return writeErr
+	}
+	return errors.Join(errs...)
+}
+
+// printFile writes matched lines of the file to w until the search of the file completes.
+func (c *grep) printFile(ctx context.Context, w io.Writer, f *grepFile) error {
+	for {
+		select {
+		case line, ok := <-f.lines:
+			if !ok {
+				return nil
+			}
+			if _, err := w.Write(line); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *grep) compile(pattern string) (*regexp.Regexp, error) {
+	if !c.isExtended {
+		pattern = basicToExtended(pattern)
+	}
+	if c.isIgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// walk sends files of the nameUrl to files and jobs.
+func (c *grep) walk(ctx context.Context, sh *fssh.Shell, nameUrl string, files, jobs chan<- *grepFile) error {
+	fsys, name, err := sh.SubFS(nameUrl)
+	if err != nil {
+		return err
+	}
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return err
+	}
+	send := func(name, url string) error {
+		f := &grepFile{fsys: fsys, name: name, url: url, lines: make(chan []byte, grepBufferedLines)}
+		for _, ch := range []chan<- *grepFile{files, jobs} {
+			select {
+			case ch <- f:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	}
+	if !info.IsDir() {
+		return send(name, nameUrl)
+	}
+	if !c.isRecursive {
+		return fmt.Errorf("%s is a directory", nameUrl)
+	}
+	return fs.WalkDir(fsys, name, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d == nil || d.IsDir() {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return send(path, fssh.JoinURL(nameUrl, relPath(name, path)))
+	})
+}
+
+func (c *grep) grepFile(ctx context.Context, re *regexp.Regexp, f *grepFile, withName bool) {
+	defer close(f.lines)
+
+	r, err := f.fsys.Open(f.name)
+	if err != nil {
+		f.err = err
+		return
+	}
+	defer r.Close()
+
+	label := ""
+	if withName || c.isFilesWithMatch {
+		label = f.url
+	}
+	f.err = c.grep(&grepLineWriter{ctx: ctx, lines: f.lines}, re, &contextReader{ctx: ctx, r: r}, label)
+}
+
+// grep writes lines of r that match re to w. The label is printed before each line if it is not empty.
+func (c *grep) grep(w io.Writer, re *regexp.Regexp, r io.Reader, label string) error {
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			line = bytes.TrimSuffix(line, []byte("\n"))
+			if re.Match(line) {
+				if c.isFilesWithMatch {
+					if label == "" {
+						label = "(standard input)"
+					}
+					_, err := fmt.Fprintf(w, "%s\n", label)
+					return err
+				}
+				if err := c.printLine(w, label, n, line); err != nil {
+					return err
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c *grep) printLine(w io.Writer, label string, n int, line []byte) error {
+	var prefix strings.Builder
+	if label != "" {
+		prefix.WriteString(label)
+		prefix.WriteString(":")
+	}
+	if c.isLineNumber {
+		fmt.Fprintf(&prefix, "%d:", n)
+	}
+	_, err := fmt.Fprintf(w, "%s%s\n", prefix.String(), line)
+	return err
+}
+
+// basicToExtended converts a basic regular expression to an extended regular expression.
+// In basic regular expressions "\(", "\)", "\{", "\}", "\|", "\+" and "\?" are special and
+// the characters without backslash are literal.
+func basicToExtended(pattern string) string {
+	const specials = "(){}|+?"
+	var b strings.Builder
+	escape := false
+	for _, c := range pattern {
+		if escape {
+			escape = false
+			if !strings.ContainsRune(specials, c) {
+				b.WriteRune('\\')
+			}
+			b.WriteRune(c)
+			continue
+		}
+		if c == '\\' {
+			escape = true
+			continue
+		}
+		if strings.ContainsRune(specials, c) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+	if escape {
+		b.WriteString(`\\`)
+	}
+	return b.String()
+}
+
+func (c *grep) AutoCompleter() fssh.AutoCompleterFunc {
+	return c.autoComplete
+}
+
+func (c *grep) autoComplete(sh *fssh.Shell, arg string) ([]string, error) {
+	if c.isRecursive {
+		return sh.PrefixMatcher.Matches(sh, arg)
+	}
+	return sh.PrefixMatcher.MatchFiles(sh, arg)
+}
+
+func (c *grep) Usage(w io.Writer) {
+	name := c.Name()
+	fmt.Fprintf(w, "Usage:\n  %s ([flags]) [pattern] ([file]...)\n", name)
+	fmt.Fprintln(w, "Flags:")
+	c.FlagSet().SetOutput(w)
+	c.FlagSet().PrintDefaults()
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintf(w, "  %s ERROR FILE\n", name)
+	fmt.Fprintf(w, "  %s -r -n ERROR (s3|gs)://BUCKET/DIR\n", name)
+	fmt.Fprintf(w, "  %s -i -l -E 'error|warn' FILE1 FILE2\n", name)
+	fmt.Fprintf(w, "  cat FILE | %s ERROR\n", name)
+}
+
+func init() {
+	fssh.RegisterNewCommandFunc(newGrep)
+}
@@ -0,0 +1,129 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestGrep(t *testing.T) {
+	files := map[string]string{
+		"a.txt":       "INFO start\nERROR a1\nINFO end\n",
+		"b.txt":       "error b1\nERROR b2",
+		"d/c.txt":     "ERROR c1\n",
+		"d/sub/d.txt": "INFO d1\n",
+	}
+	tests := []struct {
+		line   string
+		stdin  string
+		want   string
+		errstr string
+	}{
+		{
+			line: "grep ERROR a.txt",
+			want: "ERROR a1\n",
+		}, {
+			line: "grep -n ERROR a.txt",
+			want: "2:ERROR a1\n",
+		}, {
+			line: "grep -i error a.txt b.txt",
+			want: "a.txt:ERROR a1\nb.txt:error b1\nb.txt:ERROR b2\n",
+		}, {
+			line: "grep -l ERROR a.txt b.txt d/sub/d.txt",
+			want: "a.txt\nb.txt\n",
+		}, {
+			line: "grep -r -n ERROR d",
+			want: "d/c.txt:1:ERROR c1\n",
+		}, {
+			line: "grep -E 'a1|b2' a.txt b.txt",
+			want: "a.txt:ERROR a1\nb.txt:ERROR b2\n",
+		}, {
+			line: "grep 'R+' a.txt",
+			want: "",
+		}, {
+			line: "grep -E 'R+ a' a.txt",
+			want: "ERROR a1\n",
+		}, {
+			line:  "grep ERROR",
+			stdin: "ERROR x\nINFO y\n",
+			want:  "ERROR x\n",
+		}, {
+			line:  "grep -l ERROR -",
+			stdin: "ERROR x\n",
+			want:  "(standard input)\n",
+		}, {
+			line:   "grep ERROR d",
+			errstr: "d is a directory",
+		}, {
+			line:   "grep ERROR a.txt missing.txt",
+			want:   "a.txt:ERROR a1\n",
+			errstr: "Open missing.txt: file does not exist",
+		},
+	}
+	for i, test := range tests {
+		setTestHome(t, nil)
+		sh, stdout, _ := newTestShell(t, "mem://", files)
+		sh.Stdin = strings.NewReader(test.stdin)
+
+		err := execTestLine(sh, test.line)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+		} else if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if got := stdout.String(); got != test.want {
+			t.Errorf("tests[%d]: got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestGrepOrder(t *testing.T) {
+	files := map[string]string{}
+	var want strings.Builder
+	for i := 0; i < 100; i++ {
+		name := fmt.Sprintf("d/%03d.txt", i)
+		files[name] = strings.Repeat("ERROR\n", grepBufferedLines*2)
+		for j := 0; j < grepBufferedLines*2; j++ {
+			fmt.Fprintf(&want, "%s:ERROR\n", name)
+		}
+	}
+	setTestHome(t, nil)
+	sh, stdout, _ := newTestShell(t, "mem://", files)
+	if err := execTestLine(sh, "grep -r ERROR d"); err != nil {
+		t.Fatal(err)
+	}
+	if got := stdout.String(); got != want.String() {
+		t.Errorf("got %d bytes; want %d bytes in the order of files", len(got), want.Len())
+	}
+}
+
+// errTestWriter returns the err after n lines are written.
+type errTestWriter struct {
+	n   int
+	err error
+}
+
+func (w *errTestWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, w.err
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestGrepWriteError(t *testing.T) {
+	files := map[string]string{}
+	for i := 0; i < 100; i++ {
+		files[fmt.Sprintf("d/%03d.txt", i)] = strings.Repeat("ERROR\n", grepBufferedLines*2)
+	}
+	setTestHome(t, nil)
+	sh, _, _ := newTestShell(t, "mem://", files)
+	wantErr := errors.New("closed")
+	sh.Stdout = &errTestWriter{n: 10, err: wantErr}
+	if err := execTestLine(sh, "grep -r ERROR d"); !errors.Is(err, wantErr) {
+		t.Errorf("got err %v; want %v", err, wantErr)
+	}
+}
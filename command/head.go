@@ -0,0 +1,121 @@
+package command
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/jarxorg/fssh"
+)
+
+type head struct {
+	flagSet *flag.FlagSet
+	lines   int
+	bytes   int64
+}
+
+func newHead() fssh.Command {
+	return &head{}
+}
+
+func (c *head) Name() string {
+	return "head"
+}
+
+func (c *head) Description() string {
+	return "display first lines of a file"
+}
+
+func (c *head) FlagSet() *flag.FlagSet {
+	if c.flagSet == nil {
+		s := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+		s.Usage = func() {}
+		s.IntVar(&c.lines, "n", 10, "number of lines")
+		s.Int64Var(&c.bytes, "c", -1, "number of bytes")
+		c.flagSet = s
+	}
+	return c.flagSet
+}
+
+func (c *head) Reset() {
+	c.lines = 10
+	c.bytes = -1
+}
+
+func (c *head) Exec(ctx context.Context, sh *fssh.Shell) error {
+	args := c.FlagSet().Args()
+	r := sh.Stdin
+	if len(args) > 0 && args[0] != "-" {
+		fsys, name, err := sh.SubFS(args[0])
+		if err != nil {
+			return err
+		}
+		var f io.ReadCloser
+		if c.bytes >= 0 {
+			f, err = fssh.OpenRange(fsys, name, 0, c.bytes)
+		} else {
+			f, err = fsys.Open(name)
+		}
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	r = &contextReader{ctx: ctx, r: r}
+	if c.bytes >= 0 {
+		_, err := io.CopyN(sh.Stdout, r, c.bytes)
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	}
+	return headLines(sh.Stdout, r, c.lines)
+}
+
+// headLines writes the first n lines of r to w.
+func headLines(w io.Writer, r io.Reader, n int) error {
+	br := bufio.NewReader(r)
+	for i := 0; i < n; i++ {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			if _, err := w.Write(line); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *head) AutoCompleter() fssh.AutoCompleterFunc {
+	return c.autoComplete
+}
+
+func (c *head) autoComplete(sh *fssh.Shell, arg string) ([]string, error) {
+	return sh.PrefixMatcher.MatchFiles(sh, arg)
+}
+
+func (c *head) Usage(w io.Writer) {
+	name := c.Name()
+	fmt.Fprintf(w, "Usage:\n  %s ([flags]) ([file])\n", name)
+	fmt.Fprintln(w, "Flags:")
+	c.FlagSet().SetOutput(w)
+	c.FlagSet().PrintDefaults()
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintf(w, "  %s FILE\n", name)
+	fmt.Fprintf(w, "  %s -n 100 (s3|gs)://BUCKET/DIR/FILE\n", name)
+	fmt.Fprintf(w, "  %s -c 1024 (s3|gs)://BUCKET/DIR/FILE # Read only the first 1024 bytes\n", name)
+	fmt.Fprintf(w, "  cat FILE | %s -n 5\n", name)
+}
+
+func init() {
+	fssh.RegisterNewCommandFunc(newHead)
+}
@@ -0,0 +1,73 @@
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHead(t *testing.T) {
+	files := map[string]string{
+		"a.txt":     "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+		"b.txt":     "1\n2",
+		"empty.txt": "",
+	}
+	tests := []struct {
+		line   string
+		stdin  string
+		want   string
+		errstr string
+	}{
+		{
+			line: "head a.txt",
+			want: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+		}, {
+			line: "head -n 2 a.txt",
+			want: "1\n2\n",
+		}, {
+			line: "head -n 5 b.txt",
+			want: "1\n2",
+		}, {
+			line: "head -c 3 a.txt",
+			want: "1\n2",
+		}, {
+			line: "head -c 10 b.txt",
+			want: "1\n2",
+		}, {
+			line: "head empty.txt",
+			want: "",
+		}, {
+			line: "head -c 10 empty.txt",
+			want: "",
+		}, {
+			line:  "head -n 1",
+			stdin: "x\ny\n",
+			want:  "x\n",
+		}, {
+			line:  "head -c 1 -",
+			stdin: "xy",
+			want:  "x",
+		}, {
+			line:   "head missing.txt",
+			errstr: "missing.txt",
+		},
+	}
+	for _, dirUrl := range []string{"mem://", ""} {
+		for i, test := range tests {
+			setTestHome(t, nil)
+			sh, stdout, _ := newTestShell(t, dirUrl, files)
+			sh.Stdin = strings.NewReader(test.stdin)
+
+			err := execTestLine(sh, test.line)
+			if test.errstr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.errstr) {
+					t.Errorf("%s tests[%d]: got err %v; want %s", dirUrl, i, err, test.errstr)
+				}
+			} else if err != nil {
+				t.Fatalf("%s tests[%d]: err %v", dirUrl, i, err)
+			}
+			if got := stdout.String(); got != test.want {
+				t.Errorf("%s tests[%d]: got %q; want %q", dirUrl, i, got, test.want)
+			}
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package command
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
@@ -36,7 +37,7 @@ func (c *help) FlagSet() *flag.FlagSet {
 func (c *help) Reset() {
 }
 
-func (c *help) Exec(sh *fssh.Shell) error {
+func (c *help) Exec(ctx context.Context, sh *fssh.Shell) error {
 	args := c.FlagSet().Args()
 	if len(args) == 0 || args[0] == c.Name() {
 		c.Usage(sh.Stdout)
@@ -1,17 +1,46 @@
 package command
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/jarxorg/fssh"
 )
 
 type ls struct {
-	flagSet *flag.FlagSet
-	isLong  bool
+	flagSet      *flag.FlagSet
+	isLong       bool
+	isRecursive  bool
+	isAll        bool
+	isSortByTime bool
+	isSortBySize bool
+	isReverse    bool
+	isHuman      bool
+	isOnePerLine bool
+	format       fssh.Format
+	filter       fssh.Filter
+	formatter    *fssh.Formatter
+}
+
+// lsEntry represents a file to list.
+type lsEntry struct {
+	fsys fssh.FS
+	// name is the name in the fsys.
+	name string
+	// label is the name to print.
+	label string
+	// url is the absolute URL for machine readable output.
+	url  string
+	info fs.FileInfo
 }
 
 func newLs() fssh.Command {
@@ -31,6 +60,15 @@ func (c *ls) FlagSet() *flag.FlagSet {
 		s := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
 		s.Usage = func() {}
 		s.BoolVar(&c.isLong, "l", false, "long format")
+		s.BoolVar(&c.isRecursive, "R", false, "list subdirectories recursively")
+		s.BoolVar(&c.isAll, "a", false, "include names starting with a dot")
+		s.BoolVar(&c.isSortByTime, "t", false, "sort by modification time, newest first")
+		s.BoolVar(&c.isSortBySize, "S", false, "sort by size, largest first")
+		s.BoolVar(&c.isReverse, "r", false, "reverse the order of the sort")
+		s.BoolVar(&c.isHuman, "h", false, "human readable sizes with the long format")
+		s.BoolVar(&c.isOnePerLine, "1", false, "list one file per line")
+		c.format.SetFlags(s)
+		c.filter.SetFlags(s)
 		c.flagSet = s
 	}
 	return c.flagSet
@@ -38,68 +76,258 @@ func (c *ls) FlagSet() *flag.FlagSet {
 
 func (c *ls) Reset() {
 	c.isLong = false
+	c.isRecursive = false
+	c.isAll = false
+	c.isSortByTime = false
+	c.isSortBySize = false
+	c.isReverse = false
+	c.isHuman = false
+	c.isOnePerLine = false
+	c.format = ""
+	c.filter.Reset()
+	c.formatter = nil
 }
 
-func (c *ls) Exec(sh *fssh.Shell) error {
+func (c *ls) Exec(ctx context.Context, sh *fssh.Shell) error {
 	args := c.FlagSet().Args()
-	name := "."
-	if len(args) > 0 {
-		name = args[0]
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	c.formatter = sh.NewFormatter(c.format)
+	var files, dirs []lsEntry
+	var errs []error
+	for _, arg := range args {
+		entries, err := c.stat(sh, arg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, e := range entries {
+			if e.info.IsDir() {
+				dirs = append(dirs, e)
+			} else {
+				files = append(files, e)
+			}
+		}
+	}
+	c.sort(files)
+	if err := c.print(sh, files); err != nil {
+		return errors.Join(err, c.formatter.Close())
+	}
+	c.sort(dirs)
+	withHeader := c.formatter.IsText() && (len(files) > 0 || len(dirs) > 1 || len(errs) > 0)
+	for i, d := range dirs {
+		if c.formatter.IsText() && (i > 0 || len(files) > 0) {
+			fmt.Fprintln(sh.Stdout)
+		}
+		var err error
+		if c.isRecursive {
+			err = c.listRecursive(ctx, sh, d.name, d)
+		} else {
+			if withHeader {
+				fmt.Fprintf(sh.Stdout, "%s:\n", d.label)
+			}
+			_, err = c.listDir(sh, d.name, d)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			if ctx.Err() != nil {
+				break
+			}
+		}
 	}
-	subFs, subName, err := sh.SubFS(name)
+	return errors.Join(append(errs, c.formatter.Close())...)
+}
+
+// stat returns the file of the arg or files matching the arg if the arg is a glob pattern.
+func (c *ls) stat(sh *fssh.Shell, arg string) ([]lsEntry, error) {
+	labels, err := sh.ExpandGlob(arg)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if fssh.IsGlobPattern(subName) {
-		matches, err := fs.Glob(subFs, subName)
+	entries := make([]lsEntry, len(labels))
+	for i, label := range labels {
+		fsys, name, err := sh.SubFS(label)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		for _, match := range matches {
-			info, err := fs.Stat(subFs, match)
-			if err != nil {
-				return err
-			}
-			c.printInfo(sh, info)
+		info, err := fs.Stat(fsys, name)
+		if err != nil {
+			return nil, err
 		}
-		return nil
+		url, err := sh.AbsURL(label)
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = lsEntry{fsys: fsys, name: name, label: label, url: url, info: info}
 	}
-	info, err := fs.Stat(subFs, subName)
+	return entries, nil
+}
+
+// listDir prints entries of the dir that match the filter and returns the listed subdirectories.
+func (c *ls) listDir(sh *fssh.Shell, root string, dir lsEntry) ([]lsEntry, error) {
+	dirEntries, err := fs.ReadDir(dir.fsys, dir.name)
 	if err != nil {
+		return nil, err
+	}
+	var entries []lsEntry
+	for _, dirEntry := range dirEntries {
+		if !c.isAll && strings.HasPrefix(dirEntry.Name(), ".") {
+			continue
+		}
+		name := path.Join(dir.name, dirEntry.Name())
+		if !c.filter.Match(relPath(root, name), dirEntry.IsDir()) {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, lsEntry{
+			fsys:  dir.fsys,
+			name:  name,
+			label: dirEntry.Name(),
+			url:   fssh.JoinURL(dir.url, dirEntry.Name()),
+			info:  info,
+		})
+	}
+	c.sort(entries)
+	if err := c.print(sh, entries); err != nil {
+		return nil, err
+	}
+
+	var subDirs []lsEntry
+	for _, e := range entries {
+		if e.info.IsDir() {
+			subDirs = append(subDirs, e)
+		}
+	}
+	return subDirs, nil
+}
+
+// listRecursive prints the dir and subdirectories like "ls -R".
+func (c *ls) listRecursive(ctx context.Context, sh *fssh.Shell, root string, dir lsEntry) error {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
-	if !info.IsDir() {
-		c.printInfo(sh, info)
-		return nil
+	if c.formatter.IsText() {
+		fmt.Fprintf(sh.Stdout, "%s:\n", dir.label)
 	}
-	entries, err := fs.ReadDir(subFs, subName)
+	subDirs, err := c.listDir(sh, root, dir)
 	if err != nil {
 		return err
 	}
-	for _, entry := range entries {
-		info, err := entry.Info()
-		if err != nil {
+	for _, subDir := range subDirs {
+		if c.formatter.IsText() {
+			fmt.Fprintln(sh.Stdout)
+		}
+		subDir.label = fssh.JoinURL(dir.label, subDir.label)
+		if err := c.listRecursive(ctx, sh, root, subDir); err != nil {
 			return err
 		}
-		c.printInfo(sh, info)
 	}
 	return nil
 }
 
-func (c *ls) printInfo(sh *fssh.Shell, info fs.FileInfo) {
-	name := info.Name()
+// sort sorts entries by name, modification time (-t) or size (-S).
+func (c *ls) sort(entries []lsEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if c.isReverse {
+			i, j = j, i
+		}
+		a, b := entries[i].info, entries[j].info
+		switch {
+		case c.isSortByTime && !a.ModTime().Equal(b.ModTime()):
+			return a.ModTime().After(b.ModTime())
+		case c.isSortBySize && a.Size() != b.Size():
+			return a.Size() > b.Size()
+		}
+		return entries[i].label < entries[j].label
+	})
+}
+
+func (c *ls) print(sh *fssh.Shell, entries []lsEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if !c.formatter.IsText() {
+		for _, e := range entries {
+			if err := c.formatter.Write(fssh.NewFileRecord(e.url, e.info)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 	if c.isLong {
-		modTime := info.ModTime().Format("2006-01-02 15:04")
-		size := fssh.DisplaySize(info.Size())
-		fmt.Fprintf(sh.Stdout, "%s %s %s %s\n", info.Mode(), modTime, size, name)
-		return
+		c.printLong(sh.Stdout, entries)
+		return nil
 	}
-	tailSlash := ""
-	if info.IsDir() {
-		tailSlash = "/"
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.label
+		if e.info.IsDir() {
+			names[i] += "/"
+		}
+	}
+	width := 0
+	if !c.isOnePerLine {
+		width = sh.ScreenWidth()
+	}
+	printColumns(sh.Stdout, names, width)
+	return nil
+}
+
+func (c *ls) printLong(w io.Writer, entries []lsEntry) {
+	sizes := make([]string, len(entries))
+	sizeWidth := 0
+	for i, e := range entries {
+		if c.isHuman {
+			sizes[i] = fssh.DisplaySize(e.info.Size())
+		} else {
+			sizes[i] = strconv.FormatInt(e.info.Size(), 10)
+		}
+		if len(sizes[i]) > sizeWidth {
+			sizeWidth = len(sizes[i])
+		}
+	}
+	for i, e := range entries {
+		modTime := e.info.ModTime().Format("2006-01-02 15:04")
+		fmt.Fprintf(w, "%s %s %*s %s\n", e.info.Mode(), modTime, sizeWidth, sizes[i], e.label)
 	}
-	fmt.Fprintf(sh.Stdout, "%s%s\n", name, tailSlash)
+}
 
+// printColumns prints names in columns that fit in the width like ls(1).
+// If the width is 0 then this prints one name per line.
+func printColumns(w io.Writer, names []string, width int) {
+	maxLen := 0
+	for _, name := range names {
+		if n := utf8.RuneCountInString(name); n > maxLen {
+			maxLen = n
+		}
+	}
+	colWidth := maxLen + 2
+	cols := 1
+	if width > 0 {
+		cols = width / colWidth
+	}
+	if cols < 1 {
+		cols = 1
+	}
+	rows := (len(names) + cols - 1) / cols
+	for row := 0; row < rows; row++ {
+		var b strings.Builder
+		for col := 0; col < cols; col++ {
+			i := col*rows + row
+			if i >= len(names) {
+				break
+			}
+			b.WriteString(names[i])
+			if i+rows < len(names) {
+				b.WriteString(strings.Repeat(" ", colWidth-utf8.RuneCountInString(names[i])))
+			}
+		}
+		fmt.Fprintln(w, b.String())
+	}
 }
 
 func (c *ls) AutoCompleter() fssh.AutoCompleterFunc {
@@ -112,13 +340,16 @@ func (c *ls) autoComplete(sh *fssh.Shell, arg string) ([]string, error) {
 
 func (c *ls) Usage(w io.Writer) {
 	name := c.Name()
-	fmt.Fprintf(w, "Usage:\n  %s ([flags]) ([dir])\n", name)
+	fmt.Fprintf(w, "Usage:\n  %s ([flags]) ([file]...)\n", name)
 	fmt.Fprintln(w, "Flags:")
 	c.FlagSet().SetOutput(w)
 	c.FlagSet().PrintDefaults()
 	fmt.Fprintln(w, "Examples:")
 	fmt.Fprintf(w, "  %s DIR\n", name)
 	fmt.Fprintf(w, "  %s (s3|gs)://BUCKET/DIR\n", name)
+	fmt.Fprintf(w, "  %s -l -t -h (s3|gs)://BUCKET/DIR\n", name)
+	fmt.Fprintf(w, "  %s -l -S -r '*.csv' 'DIR/*/*.json'\n", name)
+	fmt.Fprintf(w, "  %s -R -a -exclude '*.tmp' (s3|gs)://BUCKET/DIR\n", name)
 }
 
 func init() {
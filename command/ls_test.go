@@ -0,0 +1,159 @@
+package command
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarxorg/fssh"
+)
+
+func TestLs(t *testing.T) {
+	files := map[string]string{
+		"a.txt":       "aaa",
+		"b.txt":       "bb",
+		".hidden":     "h",
+		"d/c.txt":     "c",
+		"d/.x":        "x",
+		"d/sub/e.txt": "e",
+	}
+	tests := []struct {
+		line   string
+		want   string
+		errstr string
+	}{
+		{
+			line: "ls",
+			want: "a.txt\nb.txt\nd/\n",
+		}, {
+			line: "ls -a",
+			want: ".hidden\na.txt\nb.txt\nd/\n",
+		}, {
+			line: "ls -r",
+			want: "d/\nb.txt\na.txt\n",
+		}, {
+			line: "ls -S b.txt d/c.txt a.txt",
+			want: "a.txt\nb.txt\nd/c.txt\n",
+		}, {
+			line: "ls -S -r b.txt d/c.txt a.txt",
+			want: "d/c.txt\nb.txt\na.txt\n",
+		}, {
+			line: "ls -1 d",
+			want: "c.txt\nsub/\n",
+		}, {
+			line: "ls a.txt d",
+			want: "a.txt\n\nd:\nc.txt\nsub/\n",
+		}, {
+			line: "ls -R d",
+			want: "d:\nc.txt\nsub/\n\nd/sub:\ne.txt\n",
+		}, {
+			line: "ls -R -a d",
+			want: "d:\n.x\nc.txt\nsub/\n\nd/sub:\ne.txt\n",
+		}, {
+			line: "ls '*'",
+			want: "a.txt\nb.txt\n\nd:\nc.txt\nsub/\n",
+		}, {
+			line:   "ls missing",
+			errstr: "Open missing: file does not exist",
+		},
+	}
+	for i, test := range tests {
+		setTestHome(t, nil)
+		sh, stdout, _ := newTestShell(t, "mem://", files)
+
+		err := execTestLine(sh, test.line)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+		} else if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if got := stdout.String(); got != test.want {
+			t.Errorf("tests[%d]: got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestLsLong(t *testing.T) {
+	setTestHome(t, nil)
+	sh, stdout, _ := newTestShell(t, "", map[string]string{
+		"a.txt": "a",
+		"b.txt": strings.Repeat("b", 2048),
+		"c.txt": "ccc",
+	})
+	base := time.Date(2024, 1, 2, 3, 4, 0, 0, time.Local)
+	for i, name := range []string{"b.txt", "c.txt", "a.txt"} {
+		modTime := base.Add(time.Duration(i) * time.Hour)
+		localName, _ := fssh.LocalPath(sh.FS, path.Join(sh.Dir, name))
+		if err := os.Chtimes(localName, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{
+			line: "ls -t",
+			want: []string{"a.txt", "c.txt", "b.txt"},
+		}, {
+			line: "ls -t -r",
+			want: []string{"b.txt", "c.txt", "a.txt"},
+		}, {
+			line: "ls -l",
+			want: []string{"2024-01-02 05:04    1 a.txt", "2024-01-02 03:04 2048 b.txt", "2024-01-02 04:04    3 c.txt"},
+		}, {
+			line: "ls -l -h -S",
+			want: []string{"2024-01-02 03:04    2K b.txt", "2024-01-02 04:04    3B c.txt", "2024-01-02 05:04    1B a.txt"},
+		},
+	}
+	for i, test := range tests {
+		stdout.Reset()
+		if err := execTestLine(sh, test.line); err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+		if len(lines) != len(test.want) {
+			t.Fatalf("tests[%d]: got %q; want %q", i, lines, test.want)
+		}
+		for j, want := range test.want {
+			if !strings.HasSuffix(lines[j], want) {
+				t.Errorf("tests[%d]: lines[%d]: got %q; want suffix %q", i, j, lines[j], want)
+			}
+		}
+	}
+}
+
+func TestPrintColumns(t *testing.T) {
+	names := []string{"a", "bb", "ccc", "dddd", "e"}
+	tests := []struct {
+		width int
+		want  string
+	}{
+		{
+			width: 0,
+			want:  "a\nbb\nccc\ndddd\ne\n",
+		}, {
+			width: 5,
+			want:  "a\nbb\nccc\ndddd\ne\n",
+		}, {
+			width: 20,
+			want:  "a     ccc   e\nbb    dddd\n",
+		}, {
+			width: 80,
+			want:  "a     bb    ccc   dddd  e\n",
+		},
+	}
+	for i, test := range tests {
+		w := &bytes.Buffer{}
+		printColumns(w, names, test.width)
+		if got := w.String(); got != test.want {
+			t.Errorf("tests[%d]: got %q; want %q", i, got, test.want)
+		}
+	}
+}
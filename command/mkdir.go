@@ -0,0 +1,101 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+
+	"github.com/jarxorg/fssh"
+)
+
+type mkdir struct {
+	flagSet   *flag.FlagSet
+	isParents bool
+}
+
+func newMkdir() fssh.Command {
+	return &mkdir{}
+}
+
+func (c *mkdir) Name() string {
+	return "mkdir"
+}
+
+func (c *mkdir) Description() string {
+	return "make directories"
+}
+
+func (c *mkdir) FlagSet() *flag.FlagSet {
+	if c.flagSet == nil {
+		s := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+		s.Usage = func() {}
+		s.BoolVar(&c.isParents, "p", false, "create intermediate directories as required")
+		c.flagSet = s
+	}
+	return c.flagSet
+}
+
+func (c *mkdir) Reset() {
+	c.isParents = false
+}
+
+func (c *mkdir) Exec(ctx context.Context, sh *fssh.Shell) error {
+	args := c.FlagSet().Args()
+	if len(args) == 0 {
+		c.Usage(sh.Stderr)
+		return nil
+	}
+	for _, arg := range args {
+		fsys, name, err := sh.SubFS(arg)
+		if err != nil {
+			return err
+		}
+		if !c.isParents {
+			if _, err := fs.Stat(fsys, name); err == nil {
+				return fmt.Errorf("%s: file exists", name)
+			}
+			if dir := path.Dir(name); dir != "." {
+				info, err := fs.Stat(fsys, dir)
+				if err != nil {
+					return err
+				}
+				if !info.IsDir() {
+					return fmt.Errorf("not directory: %s", dir)
+				}
+			}
+		}
+		// NOTE: MkdirAll of object storages does nothing because directories are prefixes of objects.
+		if err := fsys.MkdirAll(name, os.ModePerm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *mkdir) AutoCompleter() fssh.AutoCompleterFunc {
+	return c.autoComplete
+}
+
+func (c *mkdir) autoComplete(sh *fssh.Shell, arg string) ([]string, error) {
+	return sh.PrefixMatcher.MatchDirs(sh, arg)
+}
+
+func (c *mkdir) Usage(w io.Writer) {
+	name := c.Name()
+	fmt.Fprintf(w, "Usage:\n  %s ([flags]) [dir]\n", name)
+	fmt.Fprintln(w, "Flags:")
+	c.FlagSet().SetOutput(w)
+	c.FlagSet().PrintDefaults()
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintf(w, "  %s DIR\n", name)
+	fmt.Fprintf(w, "  %s -p DIR1/DIR2\n", name)
+	fmt.Fprintf(w, "  %s -p (s3|gs)://BUCKET/DIR\n", name)
+}
+
+func init() {
+	fssh.RegisterNewCommandFunc(newMkdir)
+}
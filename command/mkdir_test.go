@@ -0,0 +1,57 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMkdir(t *testing.T) {
+	tests := []struct {
+		files  map[string]string
+		line   string
+		want   map[string]string
+		errstr string
+	}{
+		{
+			line: "mkdir a b",
+			want: map[string]string{"a/": "", "b/": ""},
+		}, {
+			line: "mkdir -p a/b/c",
+			want: map[string]string{"a/b/c/": ""},
+		}, {
+			files: map[string]string{"a/": ""},
+			line:  "mkdir -p a",
+			want:  map[string]string{"a/": ""},
+		}, {
+			files:  map[string]string{"a/": ""},
+			line:   "mkdir a",
+			want:   map[string]string{"a/": ""},
+			errstr: "a: file exists",
+		}, {
+			line:   "mkdir a/b",
+			want:   map[string]string{},
+			errstr: "Open a: file does not exist",
+		}, {
+			files:  map[string]string{"a": "a"},
+			line:   "mkdir a/b",
+			want:   map[string]string{"a": "a"},
+			errstr: "not directory: a",
+		},
+	}
+	for i, test := range tests {
+		setTestHome(t, nil)
+		sh, _, _ := newTestShell(t, "mem://", test.files)
+
+		err := execTestLine(sh, test.line)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+		} else if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if got := readTestFiles(t, sh.FS, sh.Dir); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d]: got %v; want %v", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"syscall"
+
+	"github.com/jarxorg/fssh"
+	"github.com/jarxorg/wfs"
+)
+
+// rename is fssh.Rename that is replaced by tests.
+var rename = fssh.Rename
+
+type mv struct {
+	flagSet *flag.FlagSet
+	cp      cp
+}
+
+func newMv() fssh.Command {
+	return &mv{}
+}
+
+func (c *mv) Name() string {
+	return "mv"
+}
+
+func (c *mv) Description() string {
+	return "move files"
+}
+
+func (c *mv) FlagSet() *flag.FlagSet {
+	if c.flagSet == nil {
+		s := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+		s.Usage = func() {}
+		s.BoolVar(&c.cp.isRecursive, "r", false, "move directories recursively")
+		s.BoolVar(&c.cp.isForce, "f", false, "forse")
+		s.BoolVar(&c.cp.isDryRun, "d", false, "dry run")
+		s.IntVar(&c.cp.jobs, "j", defaultJobs, "number of files copied in parallel if the native rename is not available")
+		c.flagSet = s
+	}
+	return c.flagSet
+}
+
+func (c *mv) Reset() {
+	c.cp.Reset()
+}
+
+func (c *mv) Exec(ctx context.Context, sh *fssh.Shell) error {
+	args := c.FlagSet().Args()
+	if len(args) < 2 {
+		c.Usage(sh.Stderr)
+		return nil
+	}
+	from, to := args[0], args[1]
+	fromFS, fromName, err := sh.SubFS(from)
+	if err != nil {
+		return err
+	}
+	toFS, toName, err := sh.SubFS(to)
+	if err != nil {
+		return err
+	}
+	fromInfo, err := fs.Stat(fromFS, fromName)
+	if err != nil {
+		return err
+	}
+	if fromInfo.IsDir() && !c.cp.isRecursive {
+		return fmt.Errorf("%s is a directory (not moved)", fromName)
+	}
+	toInfo, err := fs.Stat(toFS, toName)
+	if err == nil && toInfo.IsDir() {
+		// NOTE: The name in the directory is checked like the target.
+		toName = path.Join(toName, path.Base(fromName))
+		toInfo, err = fs.Stat(toFS, toName)
+	}
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+	} else if toInfo.IsDir() {
+		return fmt.Errorf("%s exists (not moved)", toName)
+	} else if fromInfo.IsDir() {
+		return fmt.Errorf("%s is not a directory (not moved)", toName)
+	} else if !c.cp.isForce {
+		return fmt.Errorf("%s exists (not moved)", toName)
+	}
+	if c.cp.isDryRun {
+		fmt.Fprintf(sh.Stdout, "dry-run: move %s to %s\n", fromName, toName)
+		return nil
+	}
+
+	err = rename(fromFS, fromName, toFS, toName)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, wfs.ErrNotImplemented) && !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	return c.copyAndRemove(ctx, sh, fromFS, toFS, fromName, toName, fromInfo)
+}
+
+// copyAndRemove copies the source, verifies the destination and then removes the source.
+// Checksums of copied files are verified like "cp -verify" so that the source is not removed
+// if the copy is corrupted.
+func (c *mv) copyAndRemove(ctx context.Context, sh *fssh.Shell, fromFS, toFS fssh.FS, fromName, toName string, fromInfo fs.FileInfo) error {
+	c.cp.progress = sh.NewProgress()
+	defer c.cp.progress.Stop()
+	c.cp.setOutput(sh)
+	c.cp.isVerify = true
+
+	isDir := fromInfo.IsDir()
+	if isDir {
+		if err := c.cp.copyTree(ctx, sh, fromFS, toFS, fromName, toName); err != nil {
+			return err
+		}
+	} else {
+		c.cp.progress.AddTotal(1, fromInfo.Size())
+		if err := c.cp.copyFile(ctx, sh, fromFS, toFS, fromName, toName); err != nil {
+			return err
+		}
+	}
+	if c.cp.skipped > 0 {
+		return fmt.Errorf("%d files exist in %s (not moved)", c.cp.skipped, toName)
+	}
+	if err := c.verify(fromFS, toFS, fromName, toName); err != nil {
+		return err
+	}
+	if isDir {
+		return wfs.RemoveAll(fromFS, fromName)
+	}
+	return wfs.RemoveFile(fromFS, fromName)
+}
+
+// verify checks that all files of the source were copied to the destination with the same size.
+// Contents were compared by checksums while copying.
+func (c *mv) verify(fromFS, toFS fssh.FS, fromName, toName string) error {
+	return fs.WalkDir(fromFS, fromName, func(fromPath string, d fs.DirEntry, err error) error {
+		if err != nil || d == nil || d.IsDir() {
+			return err
+		}
+		fromInfo, err := d.Info()
+		if err != nil {
+			return err
+		}
+		toPath := path.Join(toName, relPath(fromName, fromPath))
+		toInfo, err := fs.Stat(toFS, toPath)
+		if err != nil {
+			return fmt.Errorf("failed to verify %s: %w", toPath, err)
+		}
+		if toInfo.Size() != fromInfo.Size() {
+			return fmt.Errorf("failed to verify %s: size %d; want %d", toPath, toInfo.Size(), fromInfo.Size())
+		}
+		return nil
+	})
+}
+
+func (c *mv) AutoCompleter() fssh.AutoCompleterFunc {
+	return c.autoComplete
+}
+
+func (c *mv) autoComplete(sh *fssh.Shell, arg string) ([]string, error) {
+	return c.cp.autoComplete(sh, arg)
+}
+
+func (c *mv) Usage(w io.Writer) {
+	name := c.Name()
+	fmt.Fprintf(w, "Usage:\n  %s ([flags]) [from] [to]\n", name)
+	fmt.Fprintln(w, "Flags:")
+	c.FlagSet().SetOutput(w)
+	c.FlagSet().PrintDefaults()
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintf(w, "  %s FROM TO\n", name)
+	fmt.Fprintf(w, "  %s LOCAL_FILE (s3|gs)://BUCKET/DIR\n", name)
+	fmt.Fprintf(w, "  %s -r (s3|gs)://BUCKET/DIR LOCAL_DIR\n", name)
+}
+
+func init() {
+	fssh.RegisterNewCommandFunc(newMv)
+}
@@ -0,0 +1,147 @@
+package command
+
+import (
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/jarxorg/fssh"
+)
+
+func TestMv(t *testing.T) {
+	exdev := func(fromFS fssh.FS, oldname string, toFS fssh.FS, newname string) error {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.EXDEV}
+	}
+	tests := []struct {
+		dirUrl      string
+		files       map[string]string
+		line        string
+		rename      func(fromFS fssh.FS, oldname string, toFS fssh.FS, newname string) error
+		want        map[string]string
+		wantHome    map[string]string
+		wantRenamed bool
+		errstr      string
+	}{
+		{
+			files:       map[string]string{"a.txt": "a"},
+			line:        "mv a.txt b.txt",
+			want:        map[string]string{"b.txt": "a"},
+			wantRenamed: true,
+		}, {
+			files:       map[string]string{"dir/a.txt": "a", "to/": ""},
+			line:        "mv -r dir to",
+			want:        map[string]string{"to/dir/a.txt": "a"},
+			wantRenamed: true,
+		}, {
+			files:  map[string]string{"a.txt": "a", "dir/b.txt": "b"},
+			line:   "mv -r dir moved",
+			rename: exdev,
+			want:   map[string]string{"a.txt": "a", "moved/b.txt": "b"},
+		}, {
+			dirUrl:   "mem://",
+			files:    map[string]string{"a.txt": "a", "b.txt": "b"},
+			line:     "mv a.txt ~/",
+			want:     map[string]string{"b.txt": "b"},
+			wantHome: map[string]string{"a.txt": "a"},
+		}, {
+			dirUrl:   "mem://",
+			files:    map[string]string{"dir/a.txt": "a", "dir/sub/b.txt": "b"},
+			line:     "mv -r dir ~/moved",
+			want:     map[string]string{},
+			wantHome: map[string]string{"moved/a.txt": "a", "moved/sub/b.txt": "b"},
+		}, {
+			dirUrl: "mem://",
+			files:  map[string]string{"a.txt": "a", "b.txt": "b"},
+			line:   "mv a.txt b.txt",
+			want:   map[string]string{"a.txt": "a", "b.txt": "b"},
+			errstr: "b.txt exists (not moved)",
+		}, {
+			dirUrl: "mem://",
+			files:  map[string]string{"a.txt": "a", "b.txt": "b"},
+			line:   "mv -f a.txt b.txt",
+			want:   map[string]string{"b.txt": "a"},
+		}, {
+			dirUrl: "mem://",
+			files:  map[string]string{"dir/a.txt": "a"},
+			line:   "mv dir moved",
+			want:   map[string]string{"dir/a.txt": "a"},
+			errstr: "dir is a directory (not moved)",
+		}, {
+			dirUrl: "mem://",
+			files:  map[string]string{"dir/a.txt": "a", "b.txt": "b"},
+			line:   "mv -r dir b.txt",
+			want:   map[string]string{"dir/a.txt": "a", "b.txt": "b"},
+			errstr: "b.txt is not a directory (not moved)",
+		}, {
+			dirUrl: "mem://",
+			files:  map[string]string{"dir/a.txt": "a", "to/b.txt": "b"},
+			line:   "mv -r dir to",
+			want:   map[string]string{"to/b.txt": "b", "to/dir/a.txt": "a"},
+		}, {
+			files:       map[string]string{"a.txt": "a", "dir/a.txt": "b"},
+			line:        "mv -f a.txt dir",
+			want:        map[string]string{"dir/a.txt": "a"},
+			wantRenamed: true,
+		}, {
+			dirUrl: "mem://",
+			files:  map[string]string{"a.txt": "a", "dir/a.txt": "b"},
+			line:   "mv a.txt dir",
+			want:   map[string]string{"a.txt": "a", "dir/a.txt": "b"},
+			errstr: "dir/a.txt exists (not moved)",
+		}, {
+			dirUrl: "mem://",
+			files:  map[string]string{"dir/a.txt": "a", "to/dir/b.txt": "b"},
+			line:   "mv -f -r dir to",
+			want:   map[string]string{"dir/a.txt": "a", "to/dir/b.txt": "b"},
+			errstr: "to/dir exists (not moved)",
+		}, {
+			dirUrl: "mem://",
+			files:  map[string]string{"dir/a.txt": "a", "to/dir": "b"},
+			line:   "mv -r dir to",
+			want:   map[string]string{"dir/a.txt": "a", "to/dir": "b"},
+			errstr: "to/dir is not a directory (not moved)",
+		}, {
+			dirUrl: "mem://",
+			files:  map[string]string{"a.txt": "a"},
+			line:   "mv -d a.txt b.txt",
+			want:   map[string]string{"a.txt": "a"},
+		},
+	}
+	defer func() { rename = fssh.Rename }()
+	for i, test := range tests {
+		homeFS, homeDir := setTestHome(t, nil)
+		sh, _, _ := newTestShell(t, test.dirUrl, test.files)
+
+		renamed := false
+		rename = func(fromFS fssh.FS, oldname string, toFS fssh.FS, newname string) error {
+			if test.rename != nil {
+				return test.rename(fromFS, oldname, toFS, newname)
+			}
+			err := fssh.Rename(fromFS, oldname, toFS, newname)
+			renamed = err == nil
+			return err
+		}
+		err := execTestLine(sh, test.line)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+		} else if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if renamed != test.wantRenamed {
+			t.Errorf("tests[%d]: got renamed %v; want %v", i, renamed, test.wantRenamed)
+		}
+		if got := readTestFiles(t, sh.FS, sh.Dir); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d]: got %v; want %v", i, got, test.want)
+		}
+		wantHome := test.wantHome
+		if wantHome == nil {
+			wantHome = map[string]string{}
+		}
+		if got := readTestFiles(t, homeFS, homeDir); !reflect.DeepEqual(got, wantHome) {
+			t.Errorf("tests[%d]: got home %v; want %v", i, got, wantHome)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package command
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
@@ -37,7 +38,7 @@ func (c *pwd) FlagSet() *flag.FlagSet {
 func (c *pwd) Reset() {
 }
 
-func (c *pwd) Exec(sh *fssh.Shell) error {
+func (c *pwd) Exec(ctx context.Context, sh *fssh.Shell) error {
 	if sh.Protocol == "" {
 		abs, err := filepath.Abs(filepath.Join(sh.Host, sh.Dir))
 		if err != nil {
@@ -1,10 +1,14 @@
 package command
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"io/fs"
+	"os"
+	"path"
+	"sort"
 
 	"github.com/jarxorg/fssh"
 	"github.com/jarxorg/wfs"
@@ -15,6 +19,7 @@ type rm struct {
 	isRecursive bool
 	isForce     bool
 	isDryRun    bool
+	filter      fssh.Filter
 }
 
 func newRm() fssh.Command {
@@ -35,6 +40,7 @@ func (c *rm) FlagSet() *flag.FlagSet {
 		s.BoolVar(&c.isRecursive, "r", false, "remove directories recursively")
 		s.BoolVar(&c.isForce, "f", false, "forse")
 		s.BoolVar(&c.isDryRun, "d", false, "dry run")
+		c.filter.SetFlags(s)
 		c.flagSet = s
 	}
 	return c.flagSet
@@ -44,16 +50,25 @@ func (c *rm) Reset() {
 	c.isRecursive = false
 	c.isForce = false
 	c.isDryRun = false
+	c.filter.Reset()
 }
 
-func (c *rm) Exec(sh *fssh.Shell) error {
+func (c *rm) Exec(ctx context.Context, sh *fssh.Shell) error {
 	args := c.FlagSet().Args()
 	if len(args) < 1 {
 		c.Usage(sh.Stderr)
 		return nil
 	}
+	var names []string
 	for _, arg := range args {
-		fsys, name, err := sh.SubFS(arg)
+		matches, err := sh.ExpandGlob(arg)
+		if err != nil {
+			return err
+		}
+		names = append(names, matches...)
+	}
+	for _, nameUrl := range names {
+		fsys, name, err := sh.SubFS(nameUrl)
 		if err != nil {
 			return err
 		}
@@ -64,9 +79,15 @@ func (c *rm) Exec(sh *fssh.Shell) error {
 		if info.IsDir() && !c.isRecursive {
 			return fmt.Errorf("%s is a directory", name)
 		}
+		if info.IsDir() && !c.filter.IsEmpty() {
+			if err := c.removeFiltered(ctx, sh, fsys, name); err != nil {
+				return err
+			}
+			continue
+		}
 		if c.isDryRun {
 			fmt.Fprintf(sh.Stdout, "dry-run: remove %s\n", name)
-			return nil
+			continue
 		}
 		if err := wfs.RemoveAll(fsys, name); err != nil {
 			return err
@@ -75,6 +96,66 @@ func (c *rm) Exec(sh *fssh.Shell) error {
 	return nil
 }
 
+// removeFiltered removes files in the dir that match the filter and then removes directories that become empty.
+func (c *rm) removeFiltered(ctx context.Context, sh *fssh.Shell, fsys fssh.FS, dir string) error {
+	var files []string
+	err := fs.WalkDir(fsys, dir, c.filter.WalkDirFunc(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d == nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, name)
+		}
+		return nil
+	}))
+	if err != nil {
+		return err
+	}
+	dirs := map[string]bool{}
+	for _, name := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if c.isDryRun {
+			fmt.Fprintf(sh.Stdout, "dry-run: remove %s\n", name)
+			continue
+		}
+		if err := wfs.RemoveFile(fsys, name); err != nil {
+			return err
+		}
+		for p := path.Dir(name); len(p) > len(dir); p = path.Dir(p) {
+			dirs[p] = true
+		}
+		dirs[dir] = true
+	}
+	// NOTE: Remove subdirectories before the parent. Directories that have excluded files are kept.
+	sortedDirs := make([]string, 0, len(dirs))
+	for d := range dirs {
+		sortedDirs = append(sortedDirs, d)
+	}
+	sort.Slice(sortedDirs, func(i, j int) bool {
+		return len(sortedDirs[i]) > len(sortedDirs[j])
+	})
+	for _, d := range sortedDirs {
+		entries, err := fs.ReadDir(fsys, d)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if len(entries) == 0 {
+			if err := wfs.RemoveFile(fsys, d); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (c *rm) AutoCompleter() fssh.AutoCompleterFunc {
 	return c.autoComplete
 }
@@ -97,6 +178,8 @@ func (c *rm) Usage(w io.Writer) {
 	fmt.Fprintf(w, "  %s -rf DIR\n", name)
 	fmt.Fprintf(w, "  %s (s3|gs)://BUCKET/FILE\n", name)
 	fmt.Fprintf(w, "  %s -rf (s3|gs)://BUCKET/DIR\n", name)
+	fmt.Fprintf(w, "  %s -r -exclude '*.json' (s3|gs)://BUCKET/DIR\n", name)
+	fmt.Fprintf(w, "  %s '*.tmp' 'DIR/*.log'\n", name)
 }
 
 func init() {
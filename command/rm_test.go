@@ -0,0 +1,80 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRm(t *testing.T) {
+	files := map[string]string{
+		"d/a.csv":     "a",
+		"d/b.txt":     "b",
+		"d/sub/c.csv": "c",
+		"d/sub/d.txt": "d",
+		"d/csv/e.csv": "e",
+	}
+	tests := []struct {
+		files  map[string]string
+		line   string
+		want   map[string]string
+		errstr string
+	}{
+		{
+			files: map[string]string{"a.txt": "a", "b.txt": "b"},
+			line:  "rm a.txt",
+			want:  map[string]string{"b.txt": "b"},
+		}, {
+			files: map[string]string{"a.txt": "a", "b.txt": "b", "c.csv": "c"},
+			line:  "rm '*.txt'",
+			want:  map[string]string{"c.csv": "c"},
+		}, {
+			files:  map[string]string{"d/a.txt": "a"},
+			line:   "rm d",
+			want:   map[string]string{"d/a.txt": "a"},
+			errstr: "d is a directory",
+		}, {
+			files: files,
+			line:  "rm -r d",
+			want:  map[string]string{},
+		}, {
+			files: files,
+			line:  "rm -r -include '*.csv' d",
+			want:  map[string]string{"d/b.txt": "b", "d/sub/d.txt": "d"},
+		}, {
+			files: files,
+			line:  "rm -r -exclude '*.csv' d",
+			want:  map[string]string{"d/a.csv": "a", "d/sub/c.csv": "c", "d/csv/e.csv": "e"},
+		}, {
+			files: files,
+			line:  "rm -r -exclude sub/ d",
+			want:  map[string]string{"d/sub/c.csv": "c", "d/sub/d.txt": "d"},
+		}, {
+			files: files,
+			line:  "rm -r -exclude '*' -include '*.csv' d",
+			want:  map[string]string{"d/b.txt": "b", "d/sub/d.txt": "d"},
+		}, {
+			files: files,
+			line:  "rm -r -exclude '*' -include 'sub/*.csv' d",
+			want:  map[string]string{"d/a.csv": "a", "d/b.txt": "b", "d/sub/d.txt": "d", "d/csv/e.csv": "e"},
+		}, {
+			files: files,
+			line:  "rm -r -d -include '*.csv' d",
+			want:  files,
+		},
+	}
+	for i, test := range tests {
+		sh, _, _ := newTestShell(t, "mem://", test.files)
+
+		err := execTestLine(sh, test.line)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+		} else if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if got := readTestFiles(t, sh.FS, sh.Dir); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d]: got %v; want %v", i, got, test.want)
+		}
+	}
+}
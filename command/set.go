@@ -0,0 +1,89 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/jarxorg/fssh"
+)
+
+type set struct {
+	flagSet     *flag.FlagSet
+	exitOnError bool
+	format      fssh.Format
+}
+
+func newSet() fssh.Command {
+	return &set{}
+}
+
+func (c *set) Name() string {
+	return "set"
+}
+
+func (c *set) Description() string {
+	return "prints or sets shell options"
+}
+
+func (c *set) FlagSet() *flag.FlagSet {
+	if c.flagSet == nil {
+		s := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+		s.Usage = func() {}
+		s.BoolVar(&c.exitOnError, "e", false, "exit immediately if a command fails")
+		s.Var(&c.format, "format", "default output `format` of ls, stat, du and find (text, json, ndjson or csv)")
+		c.flagSet = s
+	}
+	return c.flagSet
+}
+
+func (c *set) Reset() {
+	// NOTE: Re-create flagSet because Visit reports flags set by previous executions.
+	c.flagSet = nil
+	c.exitOnError = false
+	c.format = ""
+}
+
+func (c *set) Exec(ctx context.Context, sh *fssh.Shell) error {
+	set := 0
+	c.FlagSet().Visit(func(f *flag.Flag) {
+		set++
+		switch f.Name {
+		case "e":
+			sh.ExitOnError = c.exitOnError
+		case "format":
+			sh.Format = c.format
+		}
+	})
+	if set == 0 {
+		format := sh.Format
+		if format == "" {
+			format = fssh.FormatText
+		}
+		fmt.Fprintf(sh.Stdout, "e=%v\n", sh.ExitOnError)
+		fmt.Fprintf(sh.Stdout, "format=%s\n", format)
+	}
+	return nil
+}
+
+func (c *set) AutoCompleter() fssh.AutoCompleterFunc {
+	return nil
+}
+
+func (c *set) Usage(w io.Writer) {
+	name := c.Name()
+	fmt.Fprintf(w, "Usage:\n  %s ([flags])\n", name)
+	fmt.Fprintln(w, "Flags:")
+	c.FlagSet().SetOutput(w)
+	c.FlagSet().PrintDefaults()
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintf(w, "  %s          # Show options\n", name)
+	fmt.Fprintf(w, "  %s -e       # Exit at the first error\n", name)
+	fmt.Fprintf(w, "  %s -e=false # Continue after errors\n", name)
+	fmt.Fprintf(w, "  %s -format ndjson # Print ls, stat, du and find in NDJSON\n", name)
+}
+
+func init() {
+	fssh.RegisterNewCommandFunc(newSet)
+}
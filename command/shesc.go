@@ -1,15 +1,20 @@
 package command
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"time"
 
 	"github.com/jarxorg/fssh"
 )
 
+// shEscWaitDelay is the time to wait for the interrupted subprocess before killing it.
+const shEscWaitDelay = 3 * time.Second
+
 type shEsc struct {
 	flagSet *flag.FlagSet
 }
@@ -38,7 +43,7 @@ func (c *shEsc) FlagSet() *flag.FlagSet {
 func (c *shEsc) Reset() {
 }
 
-func (c *shEsc) Exec(sh *fssh.Shell) error {
+func (c *shEsc) Exec(ctx context.Context, sh *fssh.Shell) error {
 	args := c.FlagSet().Args()
 	if len(args) == 0 {
 		c.Usage(sh.Stderr)
@@ -50,10 +55,14 @@ func (c *shEsc) Exec(sh *fssh.Shell) error {
 		return err
 	}
 
-	cmd := exec.Command(name, args[1:]...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd := exec.CommandContext(ctx, name, args[1:]...)
+	// NOTE: Interrupt the subprocess like the terminal does and kill it if it does not exit.
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = shEscWaitDelay
+	// NOTE: Processes such as vi and less require the terminal unless the streams are redirected or piped.
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = sh.TerminalStreams()
 
 	if err := cmd.Start(); err != nil {
 		return err
@@ -73,6 +82,7 @@ func (c *shEsc) Usage(w io.Writer) {
 	fmt.Fprintln(w, "Examples:")
 	fmt.Fprintln(w, "  ! ls -al")
 	fmt.Fprintln(w, "  ! vi example.txt")
+	fmt.Fprintln(w, "  cat (s3|gs)://BUCKET/FILE | ! grep ERROR")
 }
 
 func init() {
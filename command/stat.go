@@ -0,0 +1,119 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"time"
+
+	"github.com/jarxorg/fssh"
+)
+
+type stat struct {
+	flagSet *flag.FlagSet
+	format  fssh.Format
+}
+
+func newStat() fssh.Command {
+	return &stat{}
+}
+
+func (c *stat) Name() string {
+	return "stat"
+}
+
+func (c *stat) Description() string {
+	return "display file status"
+}
+
+func (c *stat) FlagSet() *flag.FlagSet {
+	if c.flagSet == nil {
+		s := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+		s.Usage = func() {}
+		c.format.SetFlags(s)
+		c.flagSet = s
+	}
+	return c.flagSet
+}
+
+func (c *stat) Reset() {
+	c.format = ""
+}
+
+func (c *stat) Exec(ctx context.Context, sh *fssh.Shell) error {
+	args := c.FlagSet().Args()
+	if len(args) == 0 {
+		c.Usage(sh.Stderr)
+		return nil
+	}
+	f := sh.NewFormatter(c.format)
+	var errs []error
+	for _, arg := range args {
+		if err := c.stat(sh, f, arg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(append(errs, f.Close())...)
+}
+
+func (c *stat) stat(sh *fssh.Shell, f *fssh.Formatter, nameUrl string) error {
+	fsys, name, err := sh.SubFS(nameUrl)
+	if err != nil {
+		return err
+	}
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return err
+	}
+	url, err := sh.AbsURL(nameUrl)
+	if err != nil {
+		return err
+	}
+	r := fssh.NewFileRecord(url, info)
+	if !f.IsText() {
+		return f.Write(r)
+	}
+	fmt.Fprintf(sh.Stdout, "url: %s\n", r.URL)
+	fmt.Fprintf(sh.Stdout, "name: %s\n", r.Name)
+	fmt.Fprintf(sh.Stdout, "size: %d\n", r.Size)
+	fmt.Fprintf(sh.Stdout, "mode: %s\n", r.Mode)
+	fmt.Fprintf(sh.Stdout, "modTime: %s\n", r.ModTime.Format(time.RFC3339))
+	fmt.Fprintf(sh.Stdout, "isDir: %v\n", r.IsDir)
+	keys := make([]string, 0, len(r.Metadata))
+	for key := range r.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(sh.Stdout, "%s: %v\n", key, r.Metadata[key])
+	}
+	return nil
+}
+
+func (c *stat) AutoCompleter() fssh.AutoCompleterFunc {
+	return c.autoComplete
+}
+
+func (c *stat) autoComplete(sh *fssh.Shell, arg string) ([]string, error) {
+	return sh.PrefixMatcher.Matches(sh, arg)
+}
+
+func (c *stat) Usage(w io.Writer) {
+	name := c.Name()
+	fmt.Fprintf(w, "Usage:\n  %s ([flags]) [file]...\n", name)
+	fmt.Fprintln(w, "Flags:")
+	c.FlagSet().SetOutput(w)
+	c.FlagSet().PrintDefaults()
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintf(w, "  %s FILE\n", name)
+	fmt.Fprintf(w, "  %s -json (s3|gs)://BUCKET/DIR/FILE\n", name)
+	fmt.Fprintf(w, "  %s -format csv FILE1 FILE2\n", name)
+}
+
+func init() {
+	fssh.RegisterNewCommandFunc(newStat)
+}
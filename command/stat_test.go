@@ -0,0 +1,119 @@
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jarxorg/fssh"
+)
+
+func TestStat(t *testing.T) {
+	files := map[string]string{"a.txt": "abc", "d/": ""}
+	tests := []struct {
+		line   string
+		want   string
+		errstr string
+	}{
+		{
+			line: "stat a.txt",
+			want: "url: mem://a.txt\nname: a.txt\nsize: 3\nmode: -rwxrwxrwx\nmodTime: 0001-01-01T00:00:00Z\nisDir: false\n",
+		}, {
+			line: "stat d",
+			want: "url: mem://d\nname: d\nsize: 0\nmode: drwxrwxrwx\nmodTime: 0001-01-01T00:00:00Z\nisDir: true\n",
+		}, {
+			line: "stat --json a.txt",
+			want: `[
+  {
+    "url": "mem://a.txt",
+    "name": "a.txt",
+    "size": 3,
+    "mode": "-rwxrwxrwx",
+    "modTime": "0001-01-01T00:00:00Z",
+    "isDir": false
+  }
+]
+`,
+		}, {
+			line: "stat -ndjson a.txt d",
+			want: `{"url":"mem://a.txt","name":"a.txt","size":3,"mode":"-rwxrwxrwx","modTime":"0001-01-01T00:00:00Z","isDir":false}
+{"url":"mem://d","name":"d","size":0,"mode":"drwxrwxrwx","modTime":"0001-01-01T00:00:00Z","isDir":true}
+`,
+		}, {
+			line:   "stat missing a.txt",
+			want:   "url: mem://a.txt\nname: a.txt\nsize: 3\nmode: -rwxrwxrwx\nmodTime: 0001-01-01T00:00:00Z\nisDir: false\n",
+			errstr: "Open missing: file does not exist",
+		},
+	}
+	for i, test := range tests {
+		setTestHome(t, nil)
+		sh, stdout, _ := newTestShell(t, "mem://", files)
+
+		err := execTestLine(sh, test.line)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+		} else if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if got := stdout.String(); got != test.want {
+			t.Errorf("tests[%d]: got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestStatLocal(t *testing.T) {
+	setTestHome(t, nil)
+	sh, stdout, _ := newTestShell(t, "", map[string]string{"a.txt": "abc"})
+	localName, _ := fssh.LocalPath(sh.FS, path.Join(sh.Dir, "a.txt"))
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(localName, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(localName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := execTestLine(sh, "stat --json a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	var got []*fssh.FileRecord
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []*fssh.FileRecord{{
+		URL:     filepath.ToSlash(localName),
+		Name:    "a.txt",
+		Size:    3,
+		Mode:    info.Mode().String(),
+		ModTime: modTime,
+	}}
+	for _, r := range got {
+		r.ModTime = r.ModTime.UTC()
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v; want %#v", got[0], want[0])
+	}
+
+	stdout.Reset()
+	if err := execTestLine(sh, "stat a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	wantText := "url: " + localName + "\nname: a.txt\nsize: 3\nmode: " + info.Mode().String() +
+		"\nmodTime: " + modTime.Local().Format(time.RFC3339) + "\nisDir: false\n"
+	if got := stdout.String(); got != wantText {
+		t.Errorf("got %q; want %q", got, wantText)
+	}
+
+	if err := execTestLine(sh, "stat missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got err %v; want not exist", err)
+	}
+}
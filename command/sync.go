@@ -0,0 +1,337 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jarxorg/fssh"
+	"github.com/jarxorg/wfs"
+)
+
+// NOTE: syncTree is not named sync to avoid conflicting with the package sync.
+type syncTree struct {
+	flagSet    *flag.FlagSet
+	isDelete   bool
+	isSizeOnly bool
+	isChecksum bool
+	isMtime    bool
+	isDryRun   bool
+	jobs       int
+	progress   *fssh.Progress
+	stdout     io.Writer
+	mutex      sync.Mutex
+	added      int
+	updated    int
+	deleted    int
+}
+
+func newSync() fssh.Command {
+	return &syncTree{}
+}
+
+func (c *syncTree) Name() string {
+	return "sync"
+}
+
+func (c *syncTree) Description() string {
+	return "synchronize directories"
+}
+
+func (c *syncTree) FlagSet() *flag.FlagSet {
+	if c.flagSet == nil {
+		s := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+		s.Usage = func() {}
+		s.BoolVar(&c.isDelete, "delete", false, "delete files that do not exist in the source")
+		s.BoolVar(&c.isSizeOnly, "size-only", false, "transfer files that differ in size")
+		s.BoolVar(&c.isChecksum, "checksum", false, "transfer files that differ in content")
+		s.BoolVar(&c.isMtime, "mtime", false, "transfer files that are newer in the source")
+		s.BoolVar(&c.isDryRun, "d", false, "dry run")
+		s.IntVar(&c.jobs, "j", defaultJobs, "number of files transferred in parallel")
+		c.flagSet = s
+	}
+	return c.flagSet
+}
+
+func (c *syncTree) Reset() {
+	c.isDelete = false
+	c.isSizeOnly = false
+	c.isChecksum = false
+	c.isMtime = false
+	c.isDryRun = false
+	c.jobs = defaultJobs
+	c.progress = nil
+	c.stdout = nil
+	c.added = 0
+	c.updated = 0
+	c.deleted = 0
+}
+
+func (c *syncTree) Exec(ctx context.Context, sh *fssh.Shell) error {
+	args := c.FlagSet().Args()
+	if len(args) < 2 {
+		c.Usage(sh.Stderr)
+		return nil
+	}
+	modes := 0
+	for _, b := range []bool{c.isSizeOnly, c.isChecksum, c.isMtime} {
+		if b {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return errors.New("-size-only, -checksum and -mtime are exclusive")
+	}
+	fromFS, fromName, err := sh.SubFS(args[0])
+	if err != nil {
+		return err
+	}
+	toFS, toName, err := sh.SubFS(args[1])
+	if err != nil {
+		return err
+	}
+	fromInfo, err := fs.Stat(fromFS, fromName)
+	if err != nil {
+		return err
+	}
+	if !fromInfo.IsDir() {
+		return fmt.Errorf("%s is not a directory", args[0])
+	}
+	toInfos, err := c.readTree(ctx, toFS, toName)
+	if err != nil {
+		return err
+	}
+
+	if !c.isDryRun {
+		c.progress = sh.NewProgress()
+		defer c.progress.Stop()
+	}
+	// NOTE: Workers write to the stdout concurrently.
+	c.stdout = fssh.NewSyncWriter(sh.Stdout)
+
+	// NOTE: List files before transferring so that the progress knows the total.
+	var errs []error
+	var tasks []syncTask
+	err = fs.WalkDir(fromFS, fromName, func(fromPath string, d fs.DirEntry, err error) error {
+		if err != nil || d == nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel := relPath(fromName, fromPath)
+		toInfo, exists := toInfos[rel]
+		delete(toInfos, rel)
+		if d.IsDir() {
+			if exists && !toInfo.IsDir() {
+				errs = append(errs, fmt.Errorf("%s is not a directory", path.Join(toName, rel)))
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if exists && toInfo.IsDir() {
+			errs = append(errs, fmt.Errorf("%s is a directory", path.Join(toName, rel)))
+			return nil
+		}
+		fromInfo, err := d.Info()
+		if err != nil {
+			return err
+		}
+		c.progress.AddTotal(1, fromInfo.Size())
+		tasks = append(tasks, syncTask{fromName: fromPath, toName: path.Join(toName, rel), rel: rel, fromInfo: fromInfo, toInfo: toInfo})
+		return nil
+	})
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	g := fssh.NewTaskGroup(ctx, c.jobs)
+	for _, t := range tasks {
+		t := t
+		started := g.Go(func(ctx context.Context) error {
+			return c.syncFile(ctx, sh, fromFS, toFS, t)
+		})
+		if !started {
+			break
+		}
+	}
+	if err := g.Wait(); err != nil {
+		// NOTE: Do not delete files if the transfer failed.
+		return errors.Join(append(errs, err)...)
+	}
+	if c.isDelete {
+		if err := c.deleteExtra(ctx, sh, toFS, toName, toInfos); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	c.progress.Printf(c.stdout, "%d added, %d updated, %d deleted\n", c.added, c.updated, c.deleted)
+	return errors.Join(errs...)
+}
+
+// readTree returns FileInfo of all files and directories in the dir by relative paths.
+// If the dir does not exist then this returns an empty map.
+func (c *syncTree) readTree(ctx context.Context, fsys fssh.FS, dir string) (map[string]fs.FileInfo, error) {
+	infos := map[string]fs.FileInfo{}
+	if _, err := fs.Stat(fsys, dir); err != nil {
+		if os.IsNotExist(err) {
+			return infos, nil
+		}
+		return nil, err
+	}
+	err := fs.WalkDir(fsys, dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d == nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		infos[relPath(dir, name)] = info
+		return nil
+	})
+	return infos, err
+}
+
+// syncTask represents a file in the source and the destination.
+type syncTask struct {
+	fromName string
+	toName   string
+	rel      string
+	fromInfo fs.FileInfo
+	toInfo   fs.FileInfo
+}
+
+func (c *syncTree) syncFile(ctx context.Context, sh *fssh.Shell, fromFS, toFS fssh.FS, t syncTask) error {
+	op := "add"
+	if t.toInfo != nil {
+		differs, err := c.differs(fromFS, toFS, t.fromName, t.toName, t.fromInfo, t.toInfo)
+		if err != nil {
+			return err
+		}
+		if !differs {
+			c.progress.SkipFile(t.fromInfo.Size())
+			return nil
+		}
+		op = "update"
+	}
+	if c.isDryRun {
+		c.progress.Printf(c.stdout, "dry-run: %s %s\n", op, t.rel)
+	} else {
+		if err := toFS.MkdirAll(path.Dir(t.toName), os.ModePerm); err != nil {
+			return err
+		}
+		if err := copyContent(ctx, fromFS, toFS, t.fromName, t.toName, t.fromInfo.Mode(), c.progress); err != nil {
+			return err
+		}
+		c.progress.DoneFile()
+		c.progress.Printf(c.stdout, "%s: %s\n", op, t.rel)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if op == "add" {
+		c.added++
+	} else {
+		c.updated++
+	}
+	return nil
+}
+
+// differs reports whether the file should be transferred.
+func (c *syncTree) differs(fromFS, toFS fssh.FS, fromName, toName string, fromInfo, toInfo fs.FileInfo) (bool, error) {
+	switch {
+	case c.isSizeOnly:
+		return fromInfo.Size() != toInfo.Size(), nil
+	case c.isMtime:
+		return fromInfo.ModTime().After(toInfo.ModTime()), nil
+	case c.isChecksum:
+		if fromInfo.Size() != toInfo.Size() {
+			return true, nil
+		}
+		sum, err := fssh.FileChecksum(fromFS, fromName)
+		if err != nil {
+			return false, err
+		}
+		err = fssh.VerifyChecksum(toFS, toName, sum)
+		if errors.Is(err, fssh.ErrChecksumMismatch) {
+			return true, nil
+		}
+		return false, err
+	}
+	return fromInfo.Size() != toInfo.Size() || fromInfo.ModTime().After(toInfo.ModTime()), nil
+}
+
+// deleteExtra deletes files and directories that do not exist in the source.
+func (c *syncTree) deleteExtra(ctx context.Context, sh *fssh.Shell, fsys fssh.FS, dir string, extras map[string]fs.FileInfo) error {
+	rels := make([]string, 0, len(extras))
+	for rel := range extras {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+	var errs []error
+	deletedDir := ""
+	for _, rel := range rels {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		if rel == "." || (deletedDir != "" && strings.HasPrefix(rel, deletedDir+"/")) {
+			continue
+		}
+		name := path.Join(dir, rel)
+		if c.isDryRun {
+			c.progress.Printf(c.stdout, "dry-run: delete %s\n", rel)
+		} else {
+			var err error
+			if extras[rel].IsDir() {
+				err = wfs.RemoveAll(fsys, name)
+			} else {
+				err = wfs.RemoveFile(fsys, name)
+			}
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			c.progress.Printf(c.stdout, "delete: %s\n", rel)
+		}
+		if extras[rel].IsDir() {
+			deletedDir = rel
+		}
+		c.deleted++
+	}
+	return errors.Join(errs...)
+}
+
+func (c *syncTree) AutoCompleter() fssh.AutoCompleterFunc {
+	return c.autoComplete
+}
+
+func (c *syncTree) autoComplete(sh *fssh.Shell, arg string) ([]string, error) {
+	return sh.PrefixMatcher.MatchDirs(sh, arg)
+}
+
+func (c *syncTree) Usage(w io.Writer) {
+	name := c.Name()
+	fmt.Fprintf(w, "Usage:\n  %s ([flags]) [from] [to]\n", name)
+	fmt.Fprintln(w, "Flags:")
+	c.FlagSet().SetOutput(w)
+	c.FlagSet().PrintDefaults()
+	fmt.Fprintln(w, "By default files that differ in size or are newer in the source are transferred.")
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintf(w, "  %s LOCAL_DIR (s3|gs)://BUCKET/DIR\n", name)
+	fmt.Fprintf(w, "  %s -delete -d s3://BUCKET/DIR gs://BUCKET/DIR\n", name)
+	fmt.Fprintf(w, "  %s -checksum (s3|gs)://BUCKET/DIR LOCAL_DIR\n", name)
+}
+
+func init() {
+	fssh.RegisterNewCommandFunc(newSync)
+}
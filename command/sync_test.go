@@ -0,0 +1,102 @@
+package command
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSync(t *testing.T) {
+	tests := []struct {
+		files     map[string]string
+		line      string
+		want      map[string]string
+		wantLines []string
+		errstr    string
+	}{
+		{
+			files: map[string]string{"d/a.txt": "a", "d/sub/b.txt": "b"},
+			line:  "sync d dst",
+			want:  map[string]string{"a.txt": "a", "sub/b.txt": "b"},
+			wantLines: []string{
+				"2 added, 0 updated, 0 deleted",
+				"add: a.txt",
+				"add: sub/b.txt",
+			},
+		}, {
+			files: map[string]string{"d/a.txt": "a", "d/b.txt": "bb", "dst/b.txt": "b", "dst/c.txt": "c"},
+			line:  "sync -size-only -delete d dst",
+			want:  map[string]string{"a.txt": "a", "b.txt": "bb"},
+			wantLines: []string{
+				"1 added, 1 updated, 1 deleted",
+				"add: a.txt",
+				"delete: c.txt",
+				"update: b.txt",
+			},
+		}, {
+			files: map[string]string{"d/a.txt": "a", "d/b.txt": "bb", "dst/b.txt": "b", "dst/c.txt": "c"},
+			line:  "sync -d -size-only -delete d dst",
+			want:  map[string]string{"b.txt": "b", "c.txt": "c"},
+			wantLines: []string{
+				"1 added, 1 updated, 1 deleted",
+				"dry-run: add a.txt",
+				"dry-run: delete c.txt",
+				"dry-run: update b.txt",
+			},
+		}, {
+			files:  map[string]string{"d/a.txt": "a"},
+			line:   "sync -size-only -checksum d dst",
+			errstr: "-size-only, -checksum and -mtime are exclusive",
+		}, {
+			files:  map[string]string{"a.txt": "a"},
+			line:   "sync a.txt dst",
+			errstr: "a.txt is not a directory",
+		},
+	}
+	for i, test := range tests {
+		sh, stdout, _ := newTestShell(t, "mem://", test.files)
+
+		err := execTestLine(sh, test.line)
+		if test.errstr != "" {
+			if err == nil || err.Error() != test.errstr {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if got := readTestFiles(t, sh.FS, "dst"); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d]: got %v; want %v", i, got, test.want)
+		}
+		lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+		sort.Strings(lines)
+		if !reflect.DeepEqual(lines, test.wantLines) {
+			t.Errorf("tests[%d]: got %q; want %q", i, lines, test.wantLines)
+		}
+	}
+}
+
+func TestSyncJobs(t *testing.T) {
+	files := map[string]string{}
+	var want []string
+	for i := 0; i < 100; i++ {
+		name := fmt.Sprintf("%03d.txt", i)
+		files["d/"+name] = name
+		want = append(want, "add: "+name)
+	}
+	want = append(want, "100 added, 0 updated, 0 deleted")
+	sh, stdout, _ := newTestShell(t, "mem://", files)
+
+	if err := execTestLine(sh, "sync -j 8 d dst"); err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	sort.Strings(got)
+	sort.Strings(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
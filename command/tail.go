@@ -0,0 +1,267 @@
+package command
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/jarxorg/fssh"
+)
+
+const (
+	// tailChunkSize is the size of chunks read backward from the end of a file.
+	tailChunkSize = 64 * 1024
+	// tailFollowInterval is the interval of polling appended data.
+	tailFollowInterval = time.Second
+)
+
+type tail struct {
+	flagSet  *flag.FlagSet
+	lines    int
+	bytes    int64
+	isFollow bool
+}
+
+func newTail() fssh.Command {
+	return &tail{}
+}
+
+func (c *tail) Name() string {
+	return "tail"
+}
+
+func (c *tail) Description() string {
+	return "display the last part of a file"
+}
+
+func (c *tail) FlagSet() *flag.FlagSet {
+	if c.flagSet == nil {
+		s := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+		s.Usage = func() {}
+		s.IntVar(&c.lines, "n", 10, "number of lines")
+		s.Int64Var(&c.bytes, "c", -1, "number of bytes")
+		s.BoolVar(&c.isFollow, "f", false, "wait for appended data (local files only)")
+		c.flagSet = s
+	}
+	return c.flagSet
+}
+
+func (c *tail) Reset() {
+	c.lines = 10
+	c.bytes = -1
+	c.isFollow = false
+}
+
+func (c *tail) Exec(ctx context.Context, sh *fssh.Shell) error {
+	args := c.FlagSet().Args()
+	if len(args) == 0 || args[0] == "-" {
+		return c.tailStream(sh.Stdout, &contextReader{ctx: ctx, r: sh.Stdin})
+	}
+	fsys, name, err := sh.SubFS(args[0])
+	if err != nil {
+		return err
+	}
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", args[0])
+	}
+	localName, isLocal := fssh.LocalPath(fsys, name)
+	if c.isFollow && !isLocal {
+		return fmt.Errorf("-f: %s is not a local file", args[0])
+	}
+
+	if c.bytes < 0 && !fssh.CanOpenRange(fsys) {
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return c.tailStream(sh.Stdout, &contextReader{ctx: ctx, r: f})
+	}
+	offset := info.Size() - c.bytes
+	if c.bytes < 0 {
+		offset, err = c.lineOffset(fsys, name, info.Size())
+		if err != nil {
+			return err
+		}
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	r, err := fssh.OpenRange(fsys, name, offset, -1)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(sh.Stdout, &contextReader{ctx: ctx, r: r})
+	r.Close()
+	if err != nil || !c.isFollow {
+		return err
+	}
+	return c.follow(ctx, sh, localName, offset+n)
+}
+
+// lineOffset returns the offset of the last lines by reading chunks backward from the end of the file.
+func (c *tail) lineOffset(fsys fssh.FS, name string, size int64) (int64, error) {
+	if c.lines <= 0 {
+		return size, nil
+	}
+	count := 0
+	buf := make([]byte, tailChunkSize)
+	for end := size; end > 0; {
+		start := end - tailChunkSize
+		if start < 0 {
+			start = 0
+		}
+		r, err := fssh.OpenRange(fsys, name, start, end-start)
+		if err != nil {
+			return 0, err
+		}
+		chunk := buf[:end-start]
+		_, err = io.ReadFull(r, chunk)
+		r.Close()
+		if err != nil {
+			return 0, err
+		}
+		for i := len(chunk) - 1; i >= 0; i-- {
+			// NOTE: The newline at the end of the file terminates the last line.
+			if chunk[i] != '\n' || start+int64(i) == size-1 {
+				continue
+			}
+			count++
+			if count == c.lines {
+				return start + int64(i) + 1, nil
+			}
+		}
+		end = start
+	}
+	return 0, nil
+}
+
+// tailStream writes the last part of r to w keeping only the needed lines or bytes in memory.
+func (c *tail) tailStream(w io.Writer, r io.Reader) error {
+	if c.bytes >= 0 {
+		var buf []byte
+		p := make([]byte, tailChunkSize)
+		for {
+			n, err := r.Read(p)
+			buf = append(buf, p[:n]...)
+			if int64(len(buf)) > c.bytes+tailChunkSize {
+				buf = append(buf[:0], buf[int64(len(buf))-c.bytes:]...)
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if int64(len(buf)) > c.bytes {
+			buf = buf[int64(len(buf))-c.bytes:]
+		}
+		_, err := w.Write(buf)
+		return err
+	}
+	if c.lines <= 0 {
+		return nil
+	}
+	ring := make([][]byte, c.lines)
+	count := 0
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			ring[count%c.lines] = line
+			count++
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	start := count - c.lines
+	if start < 0 {
+		start = 0
+	}
+	for i := start; i < count; i++ {
+		if _, err := w.Write(ring[i%c.lines]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// follow polls the local file and writes appended data until the ctx is cancelled.
+func (c *tail) follow(ctx context.Context, sh *fssh.Shell, localName string, offset int64) error {
+	ticker := time.NewTicker(tailFollowInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		info, err := os.Stat(localName)
+		if err != nil {
+			return err
+		}
+		if info.Size() < offset {
+			fmt.Fprintf(sh.Stderr, "%s: file truncated\n", localName)
+			offset = 0
+		}
+		if info.Size() == offset {
+			continue
+		}
+		n, err := c.copyFrom(sh.Stdout, localName, offset)
+		offset += n
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c *tail) copyFrom(w io.Writer, localName string, offset int64) (int64, error) {
+	f, err := os.Open(localName)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.Copy(w, f)
+}
+
+func (c *tail) AutoCompleter() fssh.AutoCompleterFunc {
+	return c.autoComplete
+}
+
+func (c *tail) autoComplete(sh *fssh.Shell, arg string) ([]string, error) {
+	return sh.PrefixMatcher.MatchFiles(sh, arg)
+}
+
+func (c *tail) Usage(w io.Writer) {
+	name := c.Name()
+	fmt.Fprintf(w, "Usage:\n  %s ([flags]) ([file])\n", name)
+	fmt.Fprintln(w, "Flags:")
+	c.FlagSet().SetOutput(w)
+	c.FlagSet().PrintDefaults()
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintf(w, "  %s FILE\n", name)
+	fmt.Fprintf(w, "  %s -n 100 (s3|gs)://BUCKET/DIR/FILE\n", name)
+	fmt.Fprintf(w, "  %s -c 1024 (s3|gs)://BUCKET/DIR/FILE # Read only the last 1024 bytes\n", name)
+	fmt.Fprintf(w, "  %s -f LOCAL_FILE\n", name)
+}
+
+func init() {
+	fssh.RegisterNewCommandFunc(newTail)
+}
@@ -0,0 +1,109 @@
+package command
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTail(t *testing.T) {
+	files := map[string]string{
+		"a.txt":     "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+		"b.txt":     "1\n2",
+		"empty.txt": "",
+		"d/":        "",
+	}
+	tests := []struct {
+		dirUrl string
+		line   string
+		stdin  string
+		want   string
+		errstr string
+	}{
+		{
+			line: "tail a.txt",
+			want: "3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+		}, {
+			line: "tail -n 2 a.txt",
+			want: "11\n12\n",
+		}, {
+			line: "tail -n 1 b.txt",
+			want: "2",
+		}, {
+			line: "tail -n 5 b.txt",
+			want: "1\n2",
+		}, {
+			line: "tail -n 0 a.txt",
+			want: "",
+		}, {
+			line: "tail -c 3 a.txt",
+			want: "12\n",
+		}, {
+			line: "tail -c 10 b.txt",
+			want: "1\n2",
+		}, {
+			line: "tail empty.txt",
+			want: "",
+		}, {
+			line: "tail -c 10 empty.txt",
+			want: "",
+		}, {
+			line:  "tail -n 1",
+			stdin: "x\ny\n",
+			want:  "y\n",
+		}, {
+			line:  "tail -c 1 -",
+			stdin: "xy",
+			want:  "y",
+		}, {
+			line:   "tail d",
+			errstr: "d is a directory",
+		}, {
+			line:   "tail missing.txt",
+			errstr: "missing.txt",
+		}, {
+			dirUrl: "mem://",
+			line:   "tail -f a.txt",
+			errstr: "-f: a.txt is not a local file",
+		},
+	}
+	for _, dirUrl := range []string{"mem://", ""} {
+		for i, test := range tests {
+			if test.dirUrl != "" && test.dirUrl != dirUrl {
+				continue
+			}
+			setTestHome(t, nil)
+			sh, stdout, _ := newTestShell(t, dirUrl, files)
+			sh.Stdin = strings.NewReader(test.stdin)
+
+			err := execTestLine(sh, test.line)
+			if test.errstr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.errstr) {
+					t.Errorf("%s tests[%d]: got err %v; want %s", dirUrl, i, err, test.errstr)
+				}
+			} else if err != nil {
+				t.Fatalf("%s tests[%d]: err %v", dirUrl, i, err)
+			}
+			if got := stdout.String(); got != test.want {
+				t.Errorf("%s tests[%d]: got %q; want %q", dirUrl, i, got, test.want)
+			}
+		}
+	}
+}
+
+func TestTailLargeFile(t *testing.T) {
+	var b strings.Builder
+	for b.Len() < tailChunkSize*2 {
+		b.WriteString("0123456789abcdef\n")
+	}
+	b.WriteString("last1\nlast2\n")
+	for _, dirUrl := range []string{"mem://", ""} {
+		setTestHome(t, nil)
+		sh, stdout, _ := newTestShell(t, dirUrl, map[string]string{"large.txt": b.String()})
+		if err := execTestLine(sh, "tail -n 3 large.txt"); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := stdout.String(), "0123456789abcdef\nlast1\nlast2\n"; got != want {
+			t.Errorf("%s: got %q; want %q", dirUrl, got, want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/jarxorg/fssh"
+)
+
+type touch struct {
+	flagSet *flag.FlagSet
+}
+
+func newTouch() fssh.Command {
+	return &touch{}
+}
+
+func (c *touch) Name() string {
+	return "touch"
+}
+
+func (c *touch) Description() string {
+	return "change file modification times or create empty files"
+}
+
+func (c *touch) FlagSet() *flag.FlagSet {
+	if c.flagSet == nil {
+		s := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+		s.Usage = func() {}
+		c.flagSet = s
+	}
+	return c.flagSet
+}
+
+func (c *touch) Reset() {
+}
+
+func (c *touch) Exec(ctx context.Context, sh *fssh.Shell) error {
+	args := c.FlagSet().Args()
+	if len(args) == 0 {
+		c.Usage(sh.Stderr)
+		return nil
+	}
+	for _, arg := range args {
+		fsys, name, err := sh.SubFS(arg)
+		if err != nil {
+			return err
+		}
+		if err := c.touch(fsys, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *touch) touch(fsys fssh.FS, name string) error {
+	if _, err := fs.Stat(fsys, name); err == nil {
+		if localName, ok := fssh.LocalPath(fsys, name); ok {
+			now := time.Now()
+			return os.Chtimes(localName, now, now)
+		}
+		// NOTE: Objects of object storages are immutable so the modification time is not updated.
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	f, err := fsys.CreateFile(name, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func (c *touch) AutoCompleter() fssh.AutoCompleterFunc {
+	return c.autoComplete
+}
+
+func (c *touch) autoComplete(sh *fssh.Shell, arg string) ([]string, error) {
+	return sh.PrefixMatcher.MatchFiles(sh, arg)
+}
+
+func (c *touch) Usage(w io.Writer) {
+	name := c.Name()
+	fmt.Fprintf(w, "Usage:\n  %s [file]\n", name)
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintf(w, "  %s FILE\n", name)
+	fmt.Fprintf(w, "  %s (s3|gs)://BUCKET/DIR/FILE\n", name)
+}
+
+func init() {
+	fssh.RegisterNewCommandFunc(newTouch)
+}
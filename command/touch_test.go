@@ -0,0 +1,66 @@
+package command
+
+import (
+	"io/fs"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTouch(t *testing.T) {
+	tests := []struct {
+		files map[string]string
+		line  string
+		want  map[string]string
+	}{
+		{
+			line: "touch a.txt b.txt",
+			want: map[string]string{"a.txt": "", "b.txt": ""},
+		}, {
+			files: map[string]string{"a.txt": "a"},
+			line:  "touch a.txt",
+			want:  map[string]string{"a.txt": "a"},
+		}, {
+			files: map[string]string{"d/": ""},
+			line:  "touch d/a.txt",
+			want:  map[string]string{"d/a.txt": ""},
+		},
+	}
+	for i, test := range tests {
+		setTestHome(t, nil)
+		sh, _, _ := newTestShell(t, "mem://", test.files)
+
+		if err := execTestLine(sh, test.line); err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if got := readTestFiles(t, sh.FS, sh.Dir); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d]: got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestTouchLocal(t *testing.T) {
+	setTestHome(t, nil)
+	sh, _, _ := newTestShell(t, "", map[string]string{"a.txt": "a"})
+	name := path.Join(sh.Dir, "a.txt")
+	old, err := fs.Stat(sh.FS, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if err := execTestLine(sh, "touch a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := fs.Stat(sh.FS, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().After(old.ModTime()) {
+		t.Errorf("got %v; want after %v", info.ModTime(), old.ModTime())
+	}
+	if got := readTestFiles(t, sh.FS, sh.Dir); got["a.txt"] != "a" {
+		t.Errorf("got %q; want %q", got["a.txt"], "a")
+	}
+}
@@ -1,6 +1,7 @@
 package fssh
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
@@ -13,7 +14,7 @@ type testCommand struct {
 	name        string
 	description string
 	flagSet     *flag.FlagSet
-	execFunc    func(sh *Shell) error
+	execFunc    func(ctx context.Context, sh *Shell) error
 	ac          AutoCompleterFunc
 }
 
@@ -32,9 +33,9 @@ func (c *testCommand) FlagSet() *flag.FlagSet {
 	return c.flagSet
 }
 
-func (c *testCommand) Exec(sh *Shell) error {
+func (c *testCommand) Exec(ctx context.Context, sh *Shell) error {
 	if c.execFunc != nil {
-		return c.execFunc(sh)
+		return c.execFunc(ctx, sh)
 	}
 	fmt.Fprintf(sh.Stdout, "%s\n", c.name)
 	return nil
@@ -0,0 +1,186 @@
+package fssh
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config is the configuration read from ".fsshrc" or ".config/fssh/config.toml" in the home directory.
+//
+//	[s3]
+//	region = "us-east-1"
+//
+//	[s3.buckets.local-bucket]
+//	endpoint = "http://127.0.0.1:9000"
+//	path_style = true
+//
+//	[remotes.prod]
+//	protocol = "s3"
+//	bucket = "long-bucket-name-prod"
+//	prefix = "app"
+//	profile = "prod"
+type Config struct {
+	S3      S3Config          `toml:"s3"`
+	Remotes map[string]Remote `toml:"remotes"`
+}
+
+// RemoteNames returns sorted names of the remotes.
+func (c *Config) RemoteNames() []string {
+	names := make([]string, 0, len(c.Remotes))
+	for name := range c.Remotes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// S3Config is the configuration of S3. The options are used for all buckets and the options of
+// Buckets override them by bucket names.
+type S3Config struct {
+	S3Options
+	Buckets map[string]S3Options `toml:"buckets"`
+}
+
+// Remote is a named file system used like "prod:/logs".
+type Remote struct {
+	// Protocol is the protocol such as "s3", "gs", "sftp", "https" and "file".
+	Protocol string `toml:"protocol"`
+	// Bucket is the bucket of S3 or GCS.
+	Bucket string `toml:"bucket"`
+	// Host is the host of SFTP and HTTP such as "user@example.com:2222". This is used if the Bucket is empty.
+	Host string `toml:"host"`
+	// Prefix is the directory that names of the remote are relative to.
+	Prefix string `toml:"prefix"`
+	// S3Options is the endpoint, the region and the credentials profile of S3. These are used for
+	// all URLs of the bucket of the remote.
+	S3Options
+}
+
+// URL returns the URL of the name in the remote.
+func (r Remote) URL(name string) string {
+	host := r.Bucket
+	if host == "" {
+		host = r.Host
+	}
+	protocol := strings.TrimSuffix(r.Protocol, "://")
+	if protocol == "" || protocol == "file" {
+		return path.Join(host, r.Prefix, name)
+	}
+	return protocol + "://" + path.Join(host, r.Prefix, name)
+}
+
+// remotePattern matches "name:path" of a remote. URLs such as "s3://bucket" are excluded later.
+var remotePattern = regexp.MustCompile(`^([A-Za-z0-9_.-]+):(.*)$`)
+
+// ResolveRemote returns the URL of the uri that starts with the name of a remote such as "prod:/logs".
+// Other uris are returned as is.
+func ResolveRemote(uri string) (string, error) {
+	resolved, _, _, err := resolveRemote(uri)
+	return resolved, err
+}
+
+// resolveRemote returns the URL of the uri like ResolveRemote with the name and the remote of the uri.
+// If the uri does not start with the name of a remote then the name is empty.
+func resolveRemote(uri string) (string, string, Remote, error) {
+	m := remotePattern.FindStringSubmatch(uri)
+	if m == nil || strings.HasPrefix(m[2], "//") {
+		return uri, "", Remote{}, nil
+	}
+	c, err := LoadConfig()
+	if err != nil {
+		return "", "", Remote{}, err
+	}
+	r, ok := c.Remotes[m[1]]
+	if !ok {
+		return uri, "", Remote{}, nil
+	}
+	return r.URL(m[2]), m[1], r, nil
+}
+
+// ConfigPaths returns paths of the configuration files in the order of priority.
+func ConfigPaths() ([]string, error) {
+	homeDir, err := osUserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return []string{
+		filepath.Join(homeDir, fmt.Sprintf(".%src", ShellName)),
+		filepath.Join(homeDir, ".config", ShellName, "config.toml"),
+	}, nil
+}
+
+// configCache holds the configuration read by LoadConfig with the file name, the size and the
+// modification time of the file to read it again only if the file is changed.
+var configCache struct {
+	sync.Mutex
+	filename string
+	size     int64
+	modTime  time.Time
+	config   *Config
+}
+
+// LoadConfig returns the configuration read by ReadConfig. The configuration is cached until the
+// file is changed.
+func LoadConfig() (*Config, error) {
+	paths, err := ConfigPaths()
+	if err != nil {
+		return nil, err
+	}
+	filename, size, modTime := "", int64(0), time.Time{}
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		filename, size, modTime = p, info.Size(), info.ModTime()
+		break
+	}
+
+	configCache.Lock()
+	defer configCache.Unlock()
+	if configCache.config != nil && configCache.filename == filename &&
+		configCache.size == size && configCache.modTime.Equal(modTime) {
+		return configCache.config, nil
+	}
+	c, err := ReadConfig()
+	if err != nil {
+		return nil, err
+	}
+	configCache.filename, configCache.size, configCache.modTime, configCache.config = filename, size, modTime, c
+	return c, nil
+}
+
+// ReadConfig reads the first configuration file of ConfigPaths.
+// If no configuration files exist then this returns the empty Config.
+func ReadConfig() (*Config, error) {
+	paths, err := ConfigPaths()
+	if err != nil {
+		return nil, err
+	}
+	for _, filename := range paths {
+		c := &Config{}
+		_, err := toml.DecodeFile(filename, c)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		return c, nil
+	}
+	return &Config{}, nil
+}
@@ -0,0 +1,216 @@
+package fssh
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// setTestConfig sets the temporary home directory that has the configuration file of the name.
+func setTestConfig(t *testing.T, name, config string) {
+	homeDir := t.TempDir()
+	osUserHomeDirOrg := osUserHomeDir
+	osUserHomeDir = func() (string, error) {
+		return homeDir, nil
+	}
+	t.Cleanup(func() { osUserHomeDir = osUserHomeDirOrg })
+	if name == "" {
+		return
+	}
+	filename := filepath.Join(homeDir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(filename), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filename, []byte(config), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   *Config
+		errstr string
+	}{
+		{
+			want: &Config{},
+		}, {
+			name: ".config/fssh/config.toml",
+			config: `
+[s3]
+region = "ap-northeast-1"
+
+[s3.buckets.local]
+endpoint = "http://127.0.0.1:9000"
+path_style = true
+`,
+			want: &Config{
+				S3: S3Config{
+					S3Options: S3Options{Region: "ap-northeast-1"},
+					Buckets: map[string]S3Options{
+						"local": {Endpoint: "http://127.0.0.1:9000", PathStyle: aws.Bool(true)},
+					},
+				},
+			},
+		}, {
+			name: ".fsshrc",
+			config: `
+[s3]
+insecure_skip_verify = true
+`,
+			want: &Config{
+				S3: S3Config{S3Options: S3Options{InsecureSkipVerify: aws.Bool(true)}},
+			},
+		}, {
+			name:   ".fsshrc",
+			config: "[s3]\nregion = 1\n",
+			errstr: "incompatible types: TOML value has type int64; destination has type string",
+		},
+	}
+	for i, test := range tests {
+		setTestConfig(t, test.name, test.config)
+		got, err := ReadConfig()
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d]: no error; want %s", i, test.errstr)
+			}
+			if !strings.HasSuffix(err.Error(), test.errstr) {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d]: got %#v; want %#v", i, got, test.want)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	setTestConfig(t, ".fsshrc", "[s3]\nregion = \"ap-northeast-1\"\n")
+	c1, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c1 != c2 {
+		t.Errorf("got a new config; want the cached config")
+	}
+
+	homeDir, _ := osUserHomeDir()
+	filename := filepath.Join(homeDir, ".fsshrc")
+	if err := os.WriteFile(filename, []byte("[s3]\nregion = 1\n"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filename, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	errstr := filename + ": toml: line 2 (last key \"s3.region\"): incompatible types: TOML value has type int64; destination has type string"
+	if _, err := LoadConfig(); err == nil || err.Error() != errstr {
+		t.Errorf("got err %v; want %s", err, errstr)
+	}
+	if _, err := NewScriptShell("mem://"); err == nil || err.Error() != errstr {
+		t.Errorf("got err %v; want %s", err, errstr)
+	}
+}
+
+func TestResolveRemote(t *testing.T) {
+	setTestConfig(t, ".config/fssh/config.toml", `
+[remotes.prod]
+protocol = "s3"
+bucket = "long-bucket-name-prod"
+prefix = "app"
+profile = "prod"
+
+[remotes.backup]
+protocol = "sftp://"
+host = "user@example.com:2222"
+prefix = "/backup"
+
+[remotes.logs]
+protocol = "file"
+prefix = "/var/log"
+`)
+	tests := []struct {
+		uri          string
+		want         string
+		wantProtocol string
+		wantHost     string
+		wantFilename string
+	}{
+		{
+			uri:          "prod:/logs",
+			want:         "s3://long-bucket-name-prod/app/logs",
+			wantProtocol: "s3://",
+			wantHost:     "long-bucket-name-prod",
+			wantFilename: "app/logs",
+		}, {
+			uri:          "prod:",
+			want:         "s3://long-bucket-name-prod/app",
+			wantProtocol: "s3://",
+			wantHost:     "long-bucket-name-prod",
+			wantFilename: "app",
+		}, {
+			uri:          "backup:/daily",
+			want:         "sftp://user@example.com:2222/backup/daily",
+			wantProtocol: "sftp://",
+			wantHost:     "user@example.com:2222",
+			wantFilename: "backup/daily",
+		}, {
+			uri:          "logs:/app.log",
+			want:         "/var/log/app.log",
+			wantProtocol: "",
+			wantHost:     "/",
+			wantFilename: "var/log/app.log",
+		}, {
+			uri:          "none:/a.txt",
+			want:         "none:/a.txt",
+			wantProtocol: "",
+			wantHost:     ".",
+			wantFilename: "none:/a.txt",
+		}, {
+			uri:          "s3://prod/logs",
+			want:         "s3://prod/logs",
+			wantProtocol: "s3://",
+			wantHost:     "prod",
+			wantFilename: "logs",
+		},
+	}
+	for i, test := range tests {
+		got, err := ResolveRemote(test.uri)
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if got != test.want {
+			t.Errorf("tests[%d]: got %s; want %s", i, got, test.want)
+		}
+		gotProtocol, gotHost, gotFilename, err := ParseURI(test.uri)
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if gotProtocol != test.wantProtocol || gotHost != test.wantHost || gotFilename != test.wantFilename {
+			t.Errorf("tests[%d]: got %q, %q, %q; want %q, %q, %q", i,
+				gotProtocol, gotHost, gotFilename, test.wantProtocol, test.wantHost, test.wantFilename)
+		}
+	}
+
+	opts, err := newS3Options("long-bucket-name-prod", "prod:/logs", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Profile != "prod" {
+		t.Errorf("got profile %q; want %q", opts.Profile, "prod")
+	}
+}
@@ -0,0 +1,178 @@
+package fssh
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// Filter matches relative paths with include and exclude patterns in the gitignore style.
+// The last matching pattern decides whether a path is included. Paths that match no pattern are included
+// unless include patterns are added by Include, then files that match no pattern are excluded.
+//
+//	*.tmp          excludes files and directories named *.tmp at any depth
+//	node_modules/  excludes directories named node_modules
+//	/build         excludes build only at the root
+//	docs/**/*.md   excludes md files at any depth under docs
+//	!keep.tmp      includes keep.tmp (in exclude files)
+type Filter struct {
+	rules      []filterRule
+	hasInclude bool
+}
+
+type filterRule struct {
+	include  bool
+	dirOnly  bool
+	anchored bool
+	segments []string
+}
+
+// IsEmpty reports whether the filter has no patterns.
+func (f *Filter) IsEmpty() bool {
+	return len(f.rules) == 0
+}
+
+// Reset removes all patterns.
+func (f *Filter) Reset() {
+	f.rules = nil
+	f.hasInclude = false
+}
+
+// Include adds the include pattern.
+func (f *Filter) Include(pattern string) error {
+	if err := f.add(pattern, true); err != nil {
+		return err
+	}
+	f.hasInclude = f.hasInclude || f.rules[len(f.rules)-1].include
+	return nil
+}
+
+// Exclude adds the exclude pattern. A pattern starting with "!" is an include pattern.
+func (f *Filter) Exclude(pattern string) error {
+	return f.add(pattern, false)
+}
+
+// ExcludeFrom adds exclude patterns read from the local file like .gitignore.
+// Empty lines and lines starting with "#" are ignored.
+func (f *Filter) ExcludeFrom(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := f.Exclude(line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (f *Filter) add(pattern string, include bool) error {
+	if strings.HasPrefix(pattern, "!") {
+		include = !include
+		pattern = pattern[1:]
+	}
+	r := filterRule{include: include}
+	if strings.HasSuffix(pattern, "/") {
+		r.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	if strings.HasPrefix(pattern, "/") {
+		r.anchored = true
+		pattern = strings.TrimLeft(pattern, "/")
+	} else if strings.Contains(pattern, "/") {
+		r.anchored = true
+	}
+	if pattern == "" {
+		return fmt.Errorf("invalid pattern: %q", pattern)
+	}
+	r.segments = strings.Split(pattern, "/")
+	for _, seg := range r.segments {
+		if _, err := path.Match(seg, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	f.rules = append(f.rules, r)
+	return nil
+}
+
+// Match reports whether the relative path is included. Directories that match no pattern are included
+// to find included files in them.
+func (f *Filter) Match(rel string, isDir bool) bool {
+	included := isDir || !f.hasInclude
+	names := strings.Split(rel, "/")
+	for _, r := range f.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.anchored {
+			if !matchSegments(r.segments, names) {
+				continue
+			}
+		} else if ok, _ := path.Match(r.segments[0], names[len(names)-1]); !ok {
+			continue
+		}
+		included = r.include
+	}
+	return included
+}
+
+// WalkDirFunc returns fs.WalkDirFunc that calls fn only for included paths under the root.
+// Excluded directories are skipped like git so files in them can not be included again, but if
+// include patterns are added by Include then they are walked to find files that match the patterns.
+func (f *Filter) WalkDirFunc(root string, fn fs.WalkDirFunc) fs.WalkDirFunc {
+	return func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d == nil || f.IsEmpty() || name == root {
+			return fn(name, d, err)
+		}
+		rel := strings.TrimLeft(strings.TrimPrefix(name, root), "/")
+		if root == "." {
+			rel = name
+		}
+		if !f.Match(rel, d.IsDir()) {
+			if d.IsDir() && !f.hasInclude {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		return fn(name, d, err)
+	}
+}
+
+// SetFlags defines -include, -exclude and -exclude-from flags that add patterns to the filter.
+func (f *Filter) SetFlags(s *flag.FlagSet) {
+	s.Func("include", "include files matching the pattern (repeatable)", f.Include)
+	s.Func("exclude", "exclude files matching the pattern (repeatable)", f.Exclude)
+	s.Func("exclude-from", "read exclude patterns from the local file like .gitignore", f.ExcludeFrom)
+}
+
+// matchSegments matches path segments with pattern segments. "**" matches zero or more segments.
+func matchSegments(patterns, names []string) bool {
+	if len(patterns) == 0 {
+		return len(names) == 0
+	}
+	if patterns[0] == "**" {
+		if matchSegments(patterns[1:], names) {
+			return true
+		}
+		return len(names) > 0 && matchSegments(patterns, names[1:])
+	}
+	if len(names) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(patterns[0], names[0]); !ok {
+		return false
+	}
+	return matchSegments(patterns[1:], names[1:])
+}
@@ -0,0 +1,150 @@
+package fssh
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jarxorg/wfs/memfs"
+)
+
+func TestFilterMatch(t *testing.T) {
+	tests := []struct {
+		includes []string
+		excludes []string
+		rel      string
+		isDir    bool
+		want     bool
+	}{
+		{rel: "a.txt", want: true},
+		{excludes: []string{"*.tmp"}, rel: "a.tmp", want: false},
+		{excludes: []string{"*.tmp"}, rel: "dir/a.tmp", want: false},
+		{excludes: []string{"*.tmp"}, rel: "a.txt", want: true},
+		{excludes: []string{"node_modules/"}, rel: "src/node_modules", isDir: true, want: false},
+		{excludes: []string{"node_modules/"}, rel: "node_modules", isDir: false, want: true},
+		{excludes: []string{"/build"}, rel: "build", isDir: true, want: false},
+		{excludes: []string{"/build"}, rel: "src/build", isDir: true, want: true},
+		{excludes: []string{"docs/**/*.md"}, rel: "docs/a.md", want: false},
+		{excludes: []string{"docs/**/*.md"}, rel: "docs/a/b/c.md", want: false},
+		{excludes: []string{"docs/**/*.md"}, rel: "src/docs/a.md", want: true},
+		{excludes: []string{"**/cache"}, rel: "a/b/cache", isDir: true, want: false},
+		{excludes: []string{"*.tmp", "!keep.tmp"}, rel: "keep.tmp", want: true},
+		{excludes: []string{"*"}, includes: []string{"*.txt"}, rel: "a.txt", want: true},
+		{excludes: []string{"*"}, includes: []string{"*.txt"}, rel: "a.csv", want: false},
+		{excludes: []string{"*"}, includes: []string{"*.txt"}, rel: "dir/a.txt", want: true},
+		{excludes: []string{"*"}, includes: []string{"*.txt"}, rel: "dir", isDir: true, want: false},
+		{includes: []string{"*.txt"}, rel: "a.txt", want: true},
+		{includes: []string{"*.txt"}, rel: "a.csv", want: false},
+		{includes: []string{"*.txt"}, rel: "dir", isDir: true, want: true},
+		{includes: []string{"*.txt"}, excludes: []string{"b.txt"}, rel: "b.txt", want: true},
+		{excludes: []string{"*.tmp", "!keep.tmp"}, rel: "a.txt", want: true},
+	}
+	for i, test := range tests {
+		f := &Filter{}
+		for _, p := range test.excludes {
+			if err := f.Exclude(p); err != nil {
+				t.Fatalf("tests[%d]: err %v", i, err)
+			}
+		}
+		for _, p := range test.includes {
+			if err := f.Include(p); err != nil {
+				t.Fatalf("tests[%d]: err %v", i, err)
+			}
+		}
+		if got := f.Match(test.rel, test.isDir); got != test.want {
+			t.Errorf("tests[%d]: got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		errstr  string
+	}{
+		{pattern: "[", errstr: `invalid pattern "[": syntax error in pattern`},
+		{pattern: "/", errstr: `invalid pattern: ""`},
+	}
+	for i, test := range tests {
+		err := (&Filter{}).Exclude(test.pattern)
+		if err == nil || err.Error() != test.errstr {
+			t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+		}
+	}
+}
+
+func TestFilterExcludeFrom(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "*-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	filename := filepath.Join(tmpDir, ".gitignore")
+	if err := os.WriteFile(filename, []byte("# comment\n\n*.tmp\n!keep.tmp\nnode_modules/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f := &Filter{}
+	if err := f.ExcludeFrom(filename); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(f.rules); got != 3 {
+		t.Errorf("got %d rules; want %d", got, 3)
+	}
+}
+
+func TestFilterWalkDirFunc(t *testing.T) {
+	fsys := memfs.New()
+	for _, name := range []string{"root/a.txt", "root/a.tmp", "root/node_modules/b.txt", "root/src/c.txt"} {
+		if _, err := fsys.WriteFile(name, []byte{}, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		includes []string
+		excludes []string
+		want     []string
+	}{
+		{
+			excludes: []string{"*.tmp", "node_modules/"},
+			want:     []string{"root", "root/a.txt", "root/src", "root/src/c.txt"},
+		}, {
+			includes: []string{"*.tmp"},
+			want:     []string{"root", "root/a.tmp", "root/node_modules", "root/src"},
+		}, {
+			excludes: []string{"*"},
+			includes: []string{"c.txt"},
+			want:     []string{"root", "root/src/c.txt"},
+		},
+	}
+	for i, test := range tests {
+		f := &Filter{}
+		for _, p := range test.excludes {
+			if err := f.Exclude(p); err != nil {
+				t.Fatalf("tests[%d]: err %v", i, err)
+			}
+		}
+		for _, p := range test.includes {
+			if err := f.Include(p); err != nil {
+				t.Fatalf("tests[%d]: err %v", i, err)
+			}
+		}
+		var got []string
+		err := fs.WalkDir(fsys, "root", f.WalkDirFunc("root", func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			got = append(got, name)
+			return nil
+		}))
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d]: got %v; want %v", i, got, test.want)
+		}
+	}
+}
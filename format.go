@@ -0,0 +1,223 @@
+package fssh
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"time"
+)
+
+// Format represents an output format of commands.
+type Format string
+
+const (
+	// FormatText is the human readable format of each command.
+	FormatText Format = "text"
+	// FormatJSON writes records as a JSON array.
+	FormatJSON Format = "json"
+	// FormatNDJSON writes a JSON object per line.
+	FormatNDJSON Format = "ndjson"
+	// FormatCSV writes records as CSV with a header line.
+	FormatCSV Format = "csv"
+)
+
+// ParseFormat parses the name of the format.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(name); f {
+	case FormatText, FormatJSON, FormatNDJSON, FormatCSV:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown format: %s", name)
+}
+
+// String returns the name of the format.
+func (f *Format) String() string {
+	if f == nil {
+		return ""
+	}
+	return string(*f)
+}
+
+// Set parses the name of the format. This implements flag.Value.
+func (f *Format) Set(name string) error {
+	parsed, err := ParseFormat(name)
+	if err != nil {
+		return err
+	}
+	*f = parsed
+	return nil
+}
+
+// SetFlags defines -format, -json and -ndjson flags that set the format.
+func (f *Format) SetFlags(s *flag.FlagSet) {
+	s.Var(f, "format", "output `format` (text, json, ndjson or csv)")
+	s.Var(&formatBoolFlag{format: f, value: FormatJSON}, "json", "same as -format json")
+	s.Var(&formatBoolFlag{format: f, value: FormatNDJSON}, "ndjson", "same as -format ndjson")
+}
+
+// formatBoolFlag is a boolean flag that sets the value to the format.
+type formatBoolFlag struct {
+	format *Format
+	value  Format
+}
+
+func (f *formatBoolFlag) String() string {
+	if f.format == nil {
+		return "false"
+	}
+	return strconv.FormatBool(*f.format == f.value)
+}
+
+func (f *formatBoolFlag) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	if b {
+		*f.format = f.value
+	} else if *f.format == f.value {
+		*f.format = ""
+	}
+	return nil
+}
+
+func (f *formatBoolFlag) IsBoolFlag() bool {
+	return true
+}
+
+// Record represents a record of machine readable output. Records are encoded by encoding/json
+// and CSVHeader and CSVRecord are used for the CSV format.
+type Record interface {
+	CSVHeader() []string
+	CSVRecord() []string
+}
+
+// FileRecord represents a file in machine readable output.
+type FileRecord struct {
+	URL      string         `json:"url"`
+	Name     string         `json:"name"`
+	Size     int64          `json:"size"`
+	Mode     string         `json:"mode"`
+	ModTime  time.Time      `json:"modTime"`
+	IsDir    bool           `json:"isDir"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+var _ Record = (*FileRecord)(nil)
+
+// NewFileRecord returns a FileRecord of the info. The metadata of S3 and GCS objects are included.
+func NewFileRecord(url string, info fs.FileInfo) *FileRecord {
+	return &FileRecord{
+		URL:      url,
+		Name:     info.Name(),
+		Size:     info.Size(),
+		Mode:     info.Mode().String(),
+		ModTime:  info.ModTime(),
+		IsDir:    info.IsDir(),
+		Metadata: FileMetadata(info),
+	}
+}
+
+// CSVHeader returns the header of CSV. The metadata is not included.
+func (r *FileRecord) CSVHeader() []string {
+	return []string{"url", "name", "size", "mode", "modTime", "isDir"}
+}
+
+// CSVRecord returns the fields of CSV.
+func (r *FileRecord) CSVRecord() []string {
+	return []string{
+		r.URL,
+		r.Name,
+		strconv.FormatInt(r.Size, 10),
+		r.Mode,
+		r.ModTime.Format(time.RFC3339),
+		strconv.FormatBool(r.IsDir),
+	}
+}
+
+// Formatter writes records in the format. In the text format commands print their own layout.
+type Formatter struct {
+	w      io.Writer
+	format Format
+	csv    *csv.Writer
+	count  int
+}
+
+// NewFormatter returns a new Formatter. If the format is empty then this uses FormatText.
+func NewFormatter(w io.Writer, format Format) *Formatter {
+	if format == "" {
+		format = FormatText
+	}
+	return &Formatter{w: w, format: format}
+}
+
+// NewFormatter returns a new Formatter that writes to the stdout. If the format is empty
+// then this uses the format of the shell that is set by "set -format".
+func (sh *Shell) NewFormatter(format Format) *Formatter {
+	if format == "" {
+		format = sh.Format
+	}
+	return NewFormatter(sh.Stdout, format)
+}
+
+// Format returns the format.
+func (f *Formatter) Format() Format {
+	return f.format
+}
+
+// IsText reports whether the format is FormatText.
+func (f *Formatter) IsText() bool {
+	return f.format == FormatText
+}
+
+// Write writes the record. This must not be called in the text format.
+func (f *Formatter) Write(r Record) error {
+	defer func() { f.count++ }()
+	switch f.format {
+	case FormatJSON:
+		b, err := json.MarshalIndent(r, "  ", "  ")
+		if err != nil {
+			return err
+		}
+		sep := ",\n  "
+		if f.count == 0 {
+			sep = "[\n  "
+		}
+		_, err = fmt.Fprintf(f.w, "%s%s", sep, b)
+		return err
+	case FormatNDJSON:
+		return json.NewEncoder(f.w).Encode(r)
+	case FormatCSV:
+		if f.csv == nil {
+			f.csv = csv.NewWriter(f.w)
+			if err := f.csv.Write(r.CSVHeader()); err != nil {
+				return err
+			}
+		}
+		return f.csv.Write(r.CSVRecord())
+	}
+	return fmt.Errorf("unsupported format: %s", f.format)
+}
+
+// Close ends the output such as closing the JSON array and flushing CSV.
+func (f *Formatter) Close() error {
+	switch f.format {
+	case FormatJSON:
+		if f.count == 0 {
+			_, err := fmt.Fprintln(f.w, "[]")
+			return err
+		}
+		_, err := fmt.Fprintln(f.w, "\n]")
+		return err
+	case FormatCSV:
+		if f.csv != nil {
+			f.csv.Flush()
+			return f.csv.Error()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package fssh
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestFormatter(t *testing.T) {
+	modTime := time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+	records := []Record{
+		&FileRecord{URL: "s3://bucket/a.txt", Name: "a.txt", Size: 1, Mode: "-rwxrwxrwx", ModTime: modTime},
+		&FileRecord{URL: "s3://bucket/dir", Name: "dir", Mode: "drwxrwxrwx", ModTime: modTime, IsDir: true},
+	}
+	tests := []struct {
+		format  Format
+		records []Record
+		want    string
+	}{
+		{
+			format:  FormatJSON,
+			records: records,
+			want: `[
+  {
+    "url": "s3://bucket/a.txt",
+    "name": "a.txt",
+    "size": 1,
+    "mode": "-rwxrwxrwx",
+    "modTime": "2023-04-05T06:07:08Z",
+    "isDir": false
+  },
+  {
+    "url": "s3://bucket/dir",
+    "name": "dir",
+    "size": 0,
+    "mode": "drwxrwxrwx",
+    "modTime": "2023-04-05T06:07:08Z",
+    "isDir": true
+  }
+]
+`,
+		}, {
+			format: FormatJSON,
+			want:   "[]\n",
+		}, {
+			format:  FormatNDJSON,
+			records: records,
+			want: `{"url":"s3://bucket/a.txt","name":"a.txt","size":1,"mode":"-rwxrwxrwx","modTime":"2023-04-05T06:07:08Z","isDir":false}
+{"url":"s3://bucket/dir","name":"dir","size":0,"mode":"drwxrwxrwx","modTime":"2023-04-05T06:07:08Z","isDir":true}
+`,
+		}, {
+			format:  FormatCSV,
+			records: records,
+			want: `url,name,size,mode,modTime,isDir
+s3://bucket/a.txt,a.txt,1,-rwxrwxrwx,2023-04-05T06:07:08Z,false
+s3://bucket/dir,dir,0,drwxrwxrwx,2023-04-05T06:07:08Z,true
+`,
+		}, {
+			format: FormatCSV,
+		},
+	}
+	for i, test := range tests {
+		w := &bytes.Buffer{}
+		f := NewFormatter(w, test.format)
+		for _, r := range test.records {
+			if err := f.Write(r); err != nil {
+				t.Fatalf("tests[%d]: err %v", i, err)
+			}
+		}
+		if err := f.Close(); err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if got := w.String(); got != test.want {
+			t.Errorf("tests[%d]: got %s; want %s", i, got, test.want)
+		}
+	}
+}
+
+func TestFormatterText(t *testing.T) {
+	f := NewFormatter(&bytes.Buffer{}, "")
+	if !f.IsText() {
+		t.Errorf("got %s; want text", f.Format())
+	}
+	if err := f.Write(&FileRecord{}); err == nil {
+		t.Errorf("no error; want an error in the text format")
+	}
+}
+
+func TestFormatSetFlags(t *testing.T) {
+	tests := []struct {
+		args   []string
+		want   Format
+		errstr string
+	}{
+		{
+			args: []string{},
+			want: "",
+		}, {
+			args: []string{"-format", "csv"},
+			want: FormatCSV,
+		}, {
+			args: []string{"-json"},
+			want: FormatJSON,
+		}, {
+			args: []string{"--ndjson"},
+			want: FormatNDJSON,
+		}, {
+			args: []string{"-json", "-json=false"},
+			want: "",
+		}, {
+			args:   []string{"-format", "xml"},
+			errstr: `invalid value "xml" for flag -format: unknown format: xml`,
+		},
+	}
+	for i, test := range tests {
+		var got Format
+		s := flag.NewFlagSet("test", flag.ContinueOnError)
+		s.SetOutput(&bytes.Buffer{})
+		got.SetFlags(s)
+		err := s.Parse(test.args)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d]: no error; want %s", i, test.errstr)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if got != test.want {
+			t.Errorf("tests[%d]: got %s; want %s", i, got, test.want)
+		}
+	}
+}
@@ -1,32 +1,68 @@
 package fssh
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
+	"net/http"
 	"os"
 	"path"
+	"path/filepath"
+	"time"
 
-	"github.com/jarxorg/gcsfs"
-	"github.com/jarxorg/s3fs"
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/jarxorg/wfs"
 	"github.com/jarxorg/wfs/memfs"
 	"github.com/jarxorg/wfs/osfs"
+	"github.com/pkg/sftp"
 )
 
 // FS is writable FS.
 type FS wfs.WriteFileFS
 
+// ErrReadOnly is returned by writing operations of read-only file systems.
+var ErrReadOnly = errors.New("read-only file system")
+
 // NewFS parses nameUrl and creates a new FS according to the protocol.
 func NewFS(filenameUrl string) (fsys FS, protocol string, host string, filename string, err error) {
+	return newFS(filenameUrl, nil)
+}
+
+// newFS creates a new FS like NewFS. The s3Queries keeps options of S3 set by URL queries for later URLs.
+func newFS(filenameUrl string, s3Queries *s3QueryOptions) (fsys FS, protocol string, host string, filename string, err error) {
 	protocol, host, filename, err = ParseURI(filenameUrl)
 	if err != nil {
 		return
 	}
+	if archiveUrl, _, ok := splitArchivePath(filenameUrl); ok {
+		var outer FS
+		var archiveName string
+		outer, _, _, archiveName, err = newFS(archiveUrl, s3Queries)
+		if err != nil {
+			return
+		}
+		fsys = newArchiveFS(outer, archiveName)
+		return
+	}
 	switch protocol {
 	case "s3://":
-		fsys = s3fs.New(host)
+		var opts S3Options
+		if opts, err = newS3Options(host, filenameUrl, s3Queries); err != nil {
+			return
+		}
+		var s3fsys *s3FS
+		if s3fsys, err = newS3FS(host, opts); err != nil {
+			return
+		}
+		fsys = s3fsys
 	case "gs://":
-		fsys = gcsfs.New(host)
+		fsys = newGCSFS(host)
+	case "sftp://":
+		fsys = newSFTPFS(host)
+	case "http://", "https://":
+		fsys = newHTTPFS(protocol + host)
 	case "mem://":
 		fsys = memfs.New()
 		err = fsys.MkdirAll(path.Join(host, filename), os.ModePerm)
@@ -38,7 +74,12 @@ func NewFS(filenameUrl string) (fsys FS, protocol string, host string, filename
 
 // NewDirFS parses dirUrl and creates a new FS according to the protocol.
 func NewDirFS(dirUrl string) (fsys FS, protocol string, host string, dir string, err error) {
-	fsys, protocol, host, dir, err = NewFS(dirUrl)
+	return newDirFS(dirUrl, nil)
+}
+
+// newDirFS creates a new FS like NewDirFS. The s3Queries keeps options of S3 set by URL queries for later URLs.
+func newDirFS(dirUrl string, s3Queries *s3QueryOptions) (fsys FS, protocol string, host string, dir string, err error) {
+	fsys, protocol, host, dir, err = newFS(dirUrl, s3Queries)
 	if err != nil {
 		return
 	}
@@ -53,3 +94,242 @@ func NewDirFS(dirUrl string) (fsys FS, protocol string, host string, dir string,
 	}
 	return
 }
+
+// AppendFileFS is the interface implemented by a file system that supports appending to a file.
+type AppendFileFS interface {
+	FS
+	// AppendFile opens the named file for appending. If the file does not exist then this creates it.
+	AppendFile(name string, mode fs.FileMode) (wfs.WriterFile, error)
+}
+
+// AppendFile opens the named file for appending.
+// If the file system does not support appending then this reads the current content and
+// writes it to a new file before returning the writer.
+func AppendFile(fsys FS, name string, mode fs.FileMode) (wfs.WriterFile, error) {
+	if afs, ok := fsys.(AppendFileFS); ok {
+		return afs.AppendFile(name, mode)
+	}
+	if localName, ok := LocalPath(fsys, name); ok {
+		if err := os.MkdirAll(filepath.Dir(localName), os.ModePerm); err != nil {
+			return nil, err
+		}
+		return os.OpenFile(localName, os.O_WRONLY|os.O_APPEND|os.O_CREATE, mode&0o666)
+	}
+	bin, err := fs.ReadFile(fsys, name)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	f, err := fsys.CreateFile(name, mode)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(bin); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// RenameFS is the interface implemented by a file system that supports renaming files.
+type RenameFS interface {
+	FS
+	// Rename renames (moves) oldname to newname.
+	Rename(oldname, newname string) error
+}
+
+// Rename renames oldname in fromFS to newname in toFS using the native rename of the file system.
+// If the file systems do not support renaming between them then this returns wfs.ErrNotImplemented.
+func Rename(fromFS FS, oldname string, toFS FS, newname string) error {
+	localOld, okOld := LocalPath(fromFS, oldname)
+	localNew, okNew := LocalPath(toFS, newname)
+	if okOld && okNew {
+		if err := os.MkdirAll(filepath.Dir(localNew), os.ModePerm); err != nil {
+			return err
+		}
+		return os.Rename(localOld, localNew)
+	}
+	if rfs, ok := fromFS.(RenameFS); ok && SameFS(fromFS, toFS) {
+		return rfs.Rename(oldname, newname)
+	}
+	return &fs.PathError{Op: "Rename", Path: oldname, Err: wfs.ErrNotImplemented}
+}
+
+// SameFS reports whether a and b access the same storage. NewFS creates a new FS for each URL so
+// file systems that implement the Same method such as "Same(other FS) bool" are compared by it.
+func SameFS(a, b FS) bool {
+	if a == b {
+		return true
+	}
+	s, ok := a.(interface{ Same(other FS) bool })
+	return ok && s.Same(b)
+}
+
+// LocalPath returns the local path of the named file if the fsys is a local file system.
+func LocalPath(fsys FS, name string) (string, bool) {
+	if ofs, ok := fsys.(*osfs.OSFS); ok {
+		return filepath.Join(ofs.Dir, filepath.FromSlash(name)), true
+	}
+	return "", false
+}
+
+// RangeReaderFS is the interface implemented by a file system that supports ranged reads.
+type RangeReaderFS interface {
+	FS
+	// OpenRange opens the named file to read length bytes from offset.
+	// If length is negative then this reads to the end of the file.
+	OpenRange(name string, offset, length int64) (io.ReadCloser, error)
+}
+
+// CanOpenRange reports whether OpenRange of the fsys reads the range without reading preceding bytes.
+func CanOpenRange(fsys FS) bool {
+	if _, ok := fsys.(RangeReaderFS); ok {
+		return true
+	}
+	_, ok := LocalPath(fsys, ".")
+	return ok
+}
+
+// OpenRange opens the named file to read length bytes from offset. If length is negative then
+// this reads to the end of the file. If the file system does not support ranged reads and the
+// file does not implement io.Seeker then this skips bytes before the offset.
+func OpenRange(fsys FS, name string, offset, length int64) (io.ReadCloser, error) {
+	if rfs, ok := fsys.(RangeReaderFS); ok {
+		return rfs.OpenRange(name, offset, length)
+	}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := f.(io.Seeker); ok {
+		_, err = s.Seek(offset, io.SeekStart)
+	} else {
+		_, err = io.CopyN(io.Discard, f, offset)
+		if err == io.EOF {
+			err = nil
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if length < 0 {
+		return f, nil
+	}
+	return &limitedReadCloser{Reader: io.LimitReader(f, length), Closer: f}, nil
+}
+
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// readerFile is a file that reads the content from the ReadCloser.
+type readerFile struct {
+	io.ReadCloser
+	info fs.FileInfo
+}
+
+func (f *readerFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+// dirFile is a directory opened by file systems that read entries by fs.ReadDirFS.
+type dirFile struct {
+	fsys    fs.ReadDirFS
+	name    string
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+var _ fs.ReadDirFile = (*dirFile)(nil)
+
+func (d *dirFile) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *dirFile) Read(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+func (d *dirFile) Close() error {
+	return nil
+}
+
+// ReadDir reads the contents of the directory like fs.ReadDirFile.
+func (d *dirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.entries == nil {
+		entries, err := d.fsys.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries = entries
+	}
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
+
+// objectInfo is fs.FileInfo of an object that holds the backend specific struct.
+type objectInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	isDir   bool
+	sys     any
+}
+
+var _ fs.FileInfo = (*objectInfo)(nil)
+
+func (i *objectInfo) Name() string {
+	return i.name
+}
+
+func (i *objectInfo) Size() int64 {
+	return i.size
+}
+
+func (i *objectInfo) Mode() fs.FileMode {
+	if i.isDir {
+		return fs.ModeDir | fs.ModePerm
+	}
+	return fs.ModePerm
+}
+
+func (i *objectInfo) ModTime() time.Time {
+	return i.modTime
+}
+
+func (i *objectInfo) IsDir() bool {
+	return i.isDir
+}
+
+func (i *objectInfo) Sys() any {
+	return i.sys
+}
+
+// FileMetadata returns attributes of the backend specific struct returned by FileInfo.Sys()
+// such as ETag, storage class and custom metadata. If the struct is unknown then this returns nil.
+func FileMetadata(info fs.FileInfo) map[string]any {
+	switch sys := info.Sys().(type) {
+	case *s3.HeadObjectOutput:
+		return s3Metadata(sys)
+	case *storage.ObjectAttrs:
+		return gcsMetadata(sys)
+	case *sftp.FileStat:
+		return sftpMetadata(sys)
+	case http.Header:
+		return httpMetadata(sys)
+	}
+	return nil
+}
@@ -1,13 +1,29 @@
 package fssh
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
-	"github.com/jarxorg/gcsfs"
-	"github.com/jarxorg/s3fs"
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/jarxorg/wfs"
 	"github.com/jarxorg/wfs/memfs"
 	"github.com/jarxorg/wfs/osfs"
+	"github.com/pkg/sftp"
 )
 
 func TestNewFS(t *testing.T) {
@@ -45,16 +61,34 @@ func TestNewFS(t *testing.T) {
 			wantDir:      ".",
 		}, {
 			nameUrl:      "s3://BUCKET/DIR",
-			wantType:     reflect.TypeOf(s3fs.New("")),
+			wantType:     reflect.TypeOf(&s3FS{}),
 			wantProtocol: "s3://",
 			wantHost:     "BUCKET",
 			wantDir:      "DIR",
 		}, {
 			nameUrl:      "gs://BUCKET/DIR",
-			wantType:     reflect.TypeOf(gcsfs.New("")),
+			wantType:     reflect.TypeOf(newGCSFS("")),
 			wantProtocol: "gs://",
 			wantHost:     "BUCKET",
 			wantDir:      "DIR",
+		}, {
+			nameUrl:      "sftp://USER@HOST:2222/DIR",
+			wantType:     reflect.TypeOf(newSFTPFS("")),
+			wantProtocol: "sftp://",
+			wantHost:     "USER@HOST:2222",
+			wantDir:      "DIR",
+		}, {
+			nameUrl:      "https://HOST:8443/DIR/",
+			wantType:     reflect.TypeOf(newHTTPFS("")),
+			wantProtocol: "https://",
+			wantHost:     "HOST:8443",
+			wantDir:      "DIR",
+		}, {
+			nameUrl:      "s3://BUCKET/DIR/build.zip!/bin",
+			wantType:     reflect.TypeOf(newArchiveFS(nil, "")),
+			wantProtocol: "s3://",
+			wantHost:     "BUCKET/DIR/build.zip!",
+			wantDir:      "bin",
 		}, {
 			nameUrl: ":",
 			errstr:  `parse ":": missing protocol scheme`,
@@ -147,3 +181,548 @@ func TestNewDirFS(t *testing.T) {
 		}
 	}
 }
+
+func TestAppendFile(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "*-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tests := []struct {
+		fsys FS
+		name string
+	}{
+		{
+			fsys: memfs.New(),
+			name: "dir/test.txt",
+		}, {
+			fsys: osfs.New(tmpDir),
+			name: "dir/test.txt",
+		},
+	}
+	for i, test := range tests {
+		for _, p := range []string{"a", "b"} {
+			f, err := AppendFile(test.fsys, test.name, os.ModePerm)
+			if err != nil {
+				t.Fatalf("tests[%d]: err %v", i, err)
+			}
+			if _, err := f.Write([]byte(p)); err != nil {
+				t.Fatalf("tests[%d]: err %v", i, err)
+			}
+			if err := f.Close(); err != nil {
+				t.Fatalf("tests[%d]: err %v", i, err)
+			}
+		}
+		got, err := fs.ReadFile(test.fsys, test.name)
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if string(got) != "ab" {
+			t.Errorf("tests[%d]: got %q; want %q", i, got, "ab")
+		}
+	}
+}
+
+func TestRename(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "*-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fromFS := osfs.New(tmpDir)
+	if _, err := fromFS.WriteFile("from/test.txt", []byte("test"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	toFS := osfs.New(tmpDir)
+	if err := Rename(fromFS, "from/test.txt", toFS, "to/dir/test.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.Stat(fromFS, "from/test.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got err %v; want %v", err, fs.ErrNotExist)
+	}
+	got, err := fs.ReadFile(toFS, "to/dir/test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "test" {
+		t.Errorf("got %q; want %q", got, "test")
+	}
+
+	memFS := memfs.New()
+	if _, err := memFS.WriteFile("test.txt", []byte("test"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	err = Rename(memFS, "test.txt", memFS, "renamed.txt")
+	if !errors.Is(err, wfs.ErrNotImplemented) {
+		t.Errorf("got err %v; want %v", err, wfs.ErrNotImplemented)
+	}
+
+	var renamed []string
+	hostFS := &testRenameFS{MemFS: memFS, host: "host", renamed: &renamed}
+	if err := Rename(hostFS, "a", &testRenameFS{MemFS: memfs.New(), host: "host"}, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a b"}; !reflect.DeepEqual(renamed, want) {
+		t.Errorf("got %v; want %v", renamed, want)
+	}
+	err = Rename(hostFS, "a", &testRenameFS{MemFS: memfs.New(), host: "other"}, "b")
+	if !errors.Is(err, wfs.ErrNotImplemented) {
+		t.Errorf("got err %v; want %v", err, wfs.ErrNotImplemented)
+	}
+}
+
+// testRenameFS is a RenameFS of a host. NewFS creates file systems of the same host for each URL.
+type testRenameFS struct {
+	*memfs.MemFS
+	host    string
+	renamed *[]string
+}
+
+func (fsys *testRenameFS) Same(other FS) bool {
+	o, ok := other.(*testRenameFS)
+	return ok && o.host == fsys.host
+}
+
+func (fsys *testRenameFS) Rename(oldname, newname string) error {
+	*fsys.renamed = append(*fsys.renamed, oldname+" "+newname)
+	return nil
+}
+
+func TestOpenRange(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "*-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tests := []struct {
+		offset int64
+		length int64
+		want   string
+	}{
+		{offset: 0, length: -1, want: "0123456789"},
+		{offset: 3, length: -1, want: "3456789"},
+		{offset: 3, length: 4, want: "3456"},
+		{offset: 8, length: 4, want: "89"},
+		{offset: 12, length: -1, want: ""},
+	}
+	for _, fsys := range []FS{memfs.New(), osfs.New(tmpDir)} {
+		if _, err := fsys.WriteFile("test.txt", []byte("0123456789"), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		for i, test := range tests {
+			r, err := OpenRange(fsys, "test.txt", test.offset, test.length)
+			if err != nil {
+				t.Fatalf("tests[%d]: err %v", i, err)
+			}
+			got, err := io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				t.Fatalf("tests[%d]: err %v", i, err)
+			}
+			if string(got) != test.want {
+				t.Errorf("tests[%d]: got %q; want %q", i, got, test.want)
+			}
+		}
+	}
+}
+
+type testS3API struct {
+	s3iface.S3API
+	gotRange string
+	head     *s3.HeadObjectOutput
+	data     string
+}
+
+func (api *testS3API) HeadObject(input *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+	return api.head, nil
+}
+
+// GetObject returns the range of the data. This responds 416 like S3 if the range starts at or
+// past the end of the data.
+func (api *testS3API) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	api.gotRange = aws.StringValue(input.Range)
+	start, end := int64(0), int64(len(api.data))-1
+	if api.gotRange != "" {
+		if n, _ := fmt.Sscanf(api.gotRange, "bytes=%d-%d", &start, &end); n == 0 {
+			return nil, fmt.Errorf("invalid range: %s", api.gotRange)
+		}
+		if start >= int64(len(api.data)) {
+			return nil, awserr.NewRequestFailure(awserr.New("InvalidRange", "The requested range is not satisfiable", nil), http.StatusRequestedRangeNotSatisfiable, "")
+		}
+		if end >= int64(len(api.data)) {
+			end = int64(len(api.data)) - 1
+		}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(api.data[start : end+1]))}, nil
+}
+
+func TestS3FSOpenRange(t *testing.T) {
+	tests := []struct {
+		data      string
+		offset    int64
+		length    int64
+		wantRange string
+		want      string
+	}{
+		{data: "0123456789", offset: 0, length: -1, wantRange: "bytes=0-", want: "0123456789"},
+		{data: "0123456789", offset: 3, length: 4, wantRange: "bytes=3-6", want: "3456"},
+		{data: "0123456789", offset: 8, length: 4, wantRange: "bytes=8-11", want: "89"},
+		{data: "0123456789", offset: 10, length: -1, wantRange: "bytes=10-", want: ""},
+		{data: "0123456789", offset: 12, length: 4, wantRange: "bytes=12-15", want: ""},
+		{data: "", offset: 0, length: -1, wantRange: "bytes=0-", want: ""},
+	}
+	for i, test := range tests {
+		api := &testS3API{data: test.data}
+		r, err := newS3FSWithAPI("BUCKET", api).OpenRange("test.txt", test.offset, test.length)
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		got, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if api.gotRange != test.wantRange {
+			t.Errorf("tests[%d]: got range %q; want %q", i, api.gotRange, test.wantRange)
+		}
+		if string(got) != test.want {
+			t.Errorf("tests[%d]: got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestS3FSStat(t *testing.T) {
+	modTime := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	head := &s3.HeadObjectOutput{
+		ContentLength: aws.Int64(4),
+		LastModified:  aws.Time(modTime),
+	}
+	info, err := newS3FSWithAPI("BUCKET", &testS3API{head: head}).Stat("dir/test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name() != "test.txt" {
+		t.Errorf("got name %s; want %s", info.Name(), "test.txt")
+	}
+	if info.Size() != 4 {
+		t.Errorf("got size %d; want %d", info.Size(), 4)
+	}
+	if !info.ModTime().Equal(modTime) {
+		t.Errorf("got modTime %v; want %v", info.ModTime(), modTime)
+	}
+	if info.Sys() != head {
+		t.Errorf("got sys %v; want %v", info.Sys(), head)
+	}
+}
+
+func TestNewS3Options(t *testing.T) {
+	setTestConfig(t, ".fsshrc", `
+[s3]
+region = "ap-northeast-1"
+endpoint = "http://config:9000"
+
+[s3.buckets.local]
+endpoint = "http://local:9000"
+path_style = true
+`)
+	t.Setenv("FSSH_S3_ENDPOINT", "http://env:9000")
+	t.Setenv("FSSH_S3_INSECURE_SKIP_VERIFY", "true")
+
+	queries := &s3QueryOptions{}
+	tests := []struct {
+		bucket  string
+		rawURL  string
+		queries *s3QueryOptions
+		want    S3Options
+		errstr  string
+	}{
+		{
+			bucket: "other",
+			rawURL: "s3://other/dir",
+			want:   S3Options{Endpoint: "http://env:9000", Region: "ap-northeast-1", InsecureSkipVerify: aws.Bool(true)},
+		}, {
+			bucket: "local",
+			rawURL: "s3://local/dir",
+			want:   S3Options{Endpoint: "http://local:9000", Region: "ap-northeast-1", PathStyle: aws.Bool(true), InsecureSkipVerify: aws.Bool(true)},
+		}, {
+			bucket: "local",
+			rawURL: "s3://local/dir?path_style=false&insecure_skip_verify=0",
+			want:   S3Options{Endpoint: "http://local:9000", Region: "ap-northeast-1", PathStyle: aws.Bool(false), InsecureSkipVerify: aws.Bool(false)},
+		}, {
+			bucket:  "query",
+			rawURL:  "s3://query/dir?endpoint=http://127.0.0.1:9000&path_style&region=us-west-2",
+			queries: queries,
+			want:    S3Options{Endpoint: "http://127.0.0.1:9000", Region: "us-west-2", PathStyle: aws.Bool(true), InsecureSkipVerify: aws.Bool(true)},
+		}, {
+			// NOTE: The options of the previous query are used.
+			bucket:  "query",
+			rawURL:  "s3://query/other",
+			queries: queries,
+			want:    S3Options{Endpoint: "http://127.0.0.1:9000", Region: "us-west-2", PathStyle: aws.Bool(true), InsecureSkipVerify: aws.Bool(true)},
+		}, {
+			bucket:  "query",
+			rawURL:  "s3://query/other?path_style=false",
+			queries: queries,
+			want:    S3Options{Endpoint: "http://127.0.0.1:9000", Region: "us-west-2", PathStyle: aws.Bool(false), InsecureSkipVerify: aws.Bool(true)},
+		}, {
+			// NOTE: The query is not kept without queries.
+			bucket: "query",
+			rawURL: "s3://query/other",
+			want:   S3Options{Endpoint: "http://env:9000", Region: "ap-northeast-1", InsecureSkipVerify: aws.Bool(true)},
+		}, {
+			bucket: "invalid",
+			rawURL: "s3://invalid/?path_style=yes",
+			errstr: `invalid path_style: "yes"`,
+		},
+	}
+	for i, test := range tests {
+		got, err := newS3Options(test.bucket, test.rawURL, test.queries)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d]: no error; want %s", i, test.errstr)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d]: got %#v; want %#v", i, got, test.want)
+		}
+	}
+}
+
+func TestNewS3OptionsRemotes(t *testing.T) {
+	setTestConfig(t, ".fsshrc", `
+[s3.buckets.b]
+region = "ap-northeast-1"
+
+[remotes.prod-ro]
+protocol = "s3"
+bucket = "b"
+profile = "reader"
+
+[remotes.prod-rw]
+protocol = "s3"
+bucket = "b"
+profile = "writer"
+`)
+	queries := &s3QueryOptions{}
+	tests := []struct {
+		rawURL string
+		want   S3Options
+	}{
+		{
+			rawURL: "prod-ro:/x",
+			want:   S3Options{Region: "ap-northeast-1", Profile: "reader"},
+		}, {
+			rawURL: "prod-rw:/x",
+			want:   S3Options{Region: "ap-northeast-1", Profile: "writer"},
+		}, {
+			rawURL: "s3://b/x",
+			want:   S3Options{Region: "ap-northeast-1"},
+		}, {
+			rawURL: "prod-rw:/x?region=us-west-2",
+			want:   S3Options{Region: "us-west-2", Profile: "writer"},
+		}, {
+			// NOTE: The query of the other remote is not used.
+			rawURL: "prod-ro:/x",
+			want:   S3Options{Region: "ap-northeast-1", Profile: "reader"},
+		},
+	}
+	for i, test := range tests {
+		got, err := newS3Options("b", test.rawURL, queries)
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d]: got %#v; want %#v", i, got, test.want)
+		}
+	}
+}
+
+func TestNewS3FSProfileError(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(configFile, []byte("[profile bad]\nrole_arn = arn:aws:iam::123456789012:role/test\nsource_profile = none\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+
+	_, _, _, _, err := NewFS("s3://BUCKET/?profile=bad")
+	if err == nil {
+		t.Fatal("no error")
+	}
+	if !strings.Contains(err.Error(), "SharedConfigAssumeRoleError") {
+		t.Errorf("got err %v; want the error of the profile", err)
+	}
+}
+
+func TestS3FSEndpoint(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_REGION", "")
+
+	var gotPath string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		io.WriteString(w, "test")
+	})
+	s := httptest.NewServer(handler)
+	defer s.Close()
+	tlsServer := httptest.NewUnstartedServer(handler)
+	tlsServer.Config.ErrorLog = log.New(io.Discard, "", 0)
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+
+	tests := []struct {
+		opts   S3Options
+		errstr string
+	}{
+		{
+			opts: S3Options{Endpoint: s.URL, PathStyle: aws.Bool(true)},
+		}, {
+			opts: S3Options{Endpoint: tlsServer.URL, PathStyle: aws.Bool(true), InsecureSkipVerify: aws.Bool(true)},
+		}, {
+			opts:   S3Options{Endpoint: tlsServer.URL, PathStyle: aws.Bool(true)},
+			errstr: "certificate",
+		},
+	}
+	for i, test := range tests {
+		gotPath = ""
+		fsys, err := newS3FS("BUCKET", test.opts)
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		r, err := fsys.OpenRange("dir/test.txt", 0, -1)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d]: no error; want %s", i, test.errstr)
+			}
+			if !strings.Contains(err.Error(), test.errstr) {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		got, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if string(got) != "test" {
+			t.Errorf("tests[%d]: got %q; want %q", i, got, "test")
+		}
+		if gotPath != "/BUCKET/dir/test.txt" {
+			t.Errorf("tests[%d]: got path %s; want %s", i, gotPath, "/BUCKET/dir/test.txt")
+		}
+	}
+}
+
+func TestS3FSOpenRangeNotSatisfiable(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		io.WriteString(w, `<Error><Code>InvalidRange</Code><Message>The requested range is not satisfiable</Message></Error>`)
+	}))
+	defer s.Close()
+
+	fsys, err := newS3FS("BUCKET", S3Options{Endpoint: s.URL, PathStyle: aws.Bool(true)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := fsys.OpenRange("empty.txt", 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got %q; want empty", got)
+	}
+}
+
+func TestFileMetadata(t *testing.T) {
+	tests := []struct {
+		info fs.FileInfo
+		want map[string]any
+	}{
+		{
+			info: &objectInfo{
+				sys: &s3.HeadObjectOutput{
+					ETag:        aws.String(`"abc"`),
+					ContentType: aws.String("text/plain"),
+					Metadata:    map[string]*string{"Key": aws.String("value")},
+					VersionId:   aws.String("v1"),
+				},
+			},
+			want: map[string]any{
+				"etag":         `"abc"`,
+				"storageClass": "STANDARD",
+				"contentType":  "text/plain",
+				"metadata":     map[string]string{"Key": "value"},
+				"versionId":    "v1",
+			},
+		}, {
+			info: &objectInfo{
+				sys: &storage.ObjectAttrs{
+					Etag:         "abc",
+					StorageClass: "NEARLINE",
+					ContentType:  "text/plain",
+					Generation:   1,
+					CRC32C:       2,
+					MD5:          []byte{0xab, 0xcd},
+				},
+			},
+			want: map[string]any{
+				"etag":           "abc",
+				"storageClass":   "NEARLINE",
+				"contentType":    "text/plain",
+				"metadata":       map[string]string{},
+				"generation":     int64(1),
+				"metageneration": int64(0),
+				"crc32c":         uint32(2),
+				"md5":            "abcd",
+			},
+		}, {
+			info: &objectInfo{
+				sys: &sftp.FileStat{UID: 1000, GID: 100},
+			},
+			want: map[string]any{
+				"uid": uint32(1000),
+				"gid": uint32(100),
+			},
+		}, {
+			info: &objectInfo{
+				sys: http.Header{"Content-Type": {"text/plain"}, "Etag": {`"abc"`}},
+			},
+			want: map[string]any{
+				"contentType": "text/plain",
+				"etag":        `"abc"`,
+			},
+		}, {
+			info: &objectInfo{},
+		},
+	}
+	for i, test := range tests {
+		got := FileMetadata(test.info)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d]: got %v; want %v", i, got, test.want)
+		}
+	}
+}
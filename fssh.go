@@ -1,19 +1,34 @@
 package fssh
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"strings"
 )
 
 // Main runs shell.
 func Main(osArgs []string) error {
+	var commands string
+	var exitOnError bool
 	flagSet := flag.NewFlagSet(ShellName, flag.ExitOnError)
+	flagSet.StringVar(&commands, "c", "", "execute commands separated by \";\" and exit")
+	flagSet.BoolVar(&exitOnError, "e", false, "exit immediately if a command fails")
 	flagSet.Usage = func() {
-		fmt.Printf("Usage:\n  %s ([dir])\n", ShellName)
+		fmt.Printf("Usage:\n  %s ([flags]) ([dir]|[script])\n", ShellName)
+		fmt.Println("Flags:")
+		flagSet.PrintDefaults()
 		fmt.Println("Examples:")
 		fmt.Printf("  %s\n", ShellName)
 		fmt.Printf("  %s DIR\n", ShellName)
 		fmt.Printf("  %s (s3|gs)://BUCKET/\n", ShellName)
+		fmt.Printf("  %s -c \"ls; cp FROM TO\" (s3|gs)://BUCKET/\n", ShellName)
+		fmt.Printf("  %s -e SCRIPT.fssh\n", ShellName)
 	}
 	if err := flagSet.Parse(osArgs[1:]); err != nil {
 		return err
@@ -24,11 +39,65 @@ func Main(osArgs []string) error {
 	if len(args) > 0 {
 		dirUrl = args[0]
 	}
+	if commands != "" {
+		return runScript(dirUrl, strings.NewReader(commands), exitOnError)
+	}
+	if len(args) > 0 {
+		isScript, err := isScriptFile(dirUrl)
+		if err != nil {
+			return err
+		}
+		if isScript {
+			fsys, _, _, name, err := NewFS(dirUrl)
+			if err != nil {
+				return err
+			}
+			f, err := fsys.Open(name)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return runScript(".", f, exitOnError)
+		}
+	}
+
 	sh, err := NewShell(dirUrl)
 	if err != nil {
 		return err
 	}
 	defer sh.Close()
 
+	sh.ExitOnError = exitOnError
 	return sh.Run()
 }
+
+// isScriptFile checks the specified url is a file not a directory.
+// If the url does not exist then this returns false to open it as a directory like before.
+func isScriptFile(nameUrl string) (bool, error) {
+	fsys, _, _, name, err := NewFS(nameUrl)
+	if err != nil {
+		return false, err
+	}
+	info, err := fs.Stat(fsys, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !info.IsDir(), nil
+}
+
+func runScript(dirUrl string, r io.Reader, exitOnError bool) error {
+	sh, err := NewScriptShell(dirUrl)
+	if err != nil {
+		return err
+	}
+	defer sh.Close()
+
+	sh.ExitOnError = exitOnError
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return sh.RunScript(ctx, r)
+}
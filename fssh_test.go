@@ -0,0 +1,52 @@
+package fssh
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsScriptFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	script := filepath.Join(tmpDir, "script.fssh")
+	if err := os.WriteFile(script, []byte("ls\n"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		nameUrl string
+		want    bool
+		errstr  string
+	}{
+		{
+			nameUrl: script,
+			want:    true,
+		}, {
+			nameUrl: tmpDir,
+			want:    false,
+		}, {
+			nameUrl: filepath.Join(tmpDir, "none"),
+			want:    false,
+		}, {
+			nameUrl: ":",
+			errstr:  `parse ":": missing protocol scheme`,
+		},
+	}
+	for i, test := range tests {
+		got, err := isScriptFile(test.nameUrl)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d]: no error; want %s", i, test.errstr)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if got != test.want {
+			t.Errorf("tests[%d]: got %v; want %v", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package fssh
+
+import (
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"path"
+	"sync"
+
+	"cloud.google.com/go/storage"
+	"github.com/jarxorg/gcsfs"
+)
+
+// gcsFS is gcsfs.GCSFS that supports ranged reads.
+type gcsFS struct {
+	*gcsfs.GCSFS
+	bucket string
+	mutex  sync.Mutex
+	client *storage.Client
+}
+
+var _ RangeReaderFS = (*gcsFS)(nil)
+
+func newGCSFS(bucket string) *gcsFS {
+	return &gcsFS{
+		GCSFS:  gcsfs.New(bucket),
+		bucket: bucket,
+	}
+}
+
+// storageClient returns the client for ranged reads. The client is created at the first call.
+func (fsys *gcsFS) storageClient() (*storage.Client, error) {
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+	if fsys.client == nil {
+		client, err := storage.NewClient(fsys.Context())
+		if err != nil {
+			return nil, err
+		}
+		fsys.client = client
+	}
+	return fsys.client, nil
+}
+
+// Stat returns a FileInfo describing the file. The Sys() of the FileInfo of an object returns *storage.ObjectAttrs.
+func (fsys *gcsFS) Stat(name string) (fs.FileInfo, error) {
+	if name == "." || !fs.ValidPath(name) {
+		return fsys.GCSFS.Stat(name)
+	}
+	client, err := fsys.storageClient()
+	if err != nil {
+		return nil, &fs.PathError{Op: "Stat", Path: name, Err: err}
+	}
+	attrs, err := client.Bucket(fsys.bucket).Object(name).Attrs(fsys.Context())
+	if err != nil {
+		// NOTE: The name may be a directory.
+		return fsys.GCSFS.Stat(name)
+	}
+	return &objectInfo{
+		name:    path.Base(name),
+		size:    attrs.Size,
+		modTime: attrs.Updated,
+		sys:     attrs,
+	}, nil
+}
+
+// OpenRange opens the named object to read length bytes from offset.
+func (fsys *gcsFS) OpenRange(name string, offset, length int64) (io.ReadCloser, error) {
+	client, err := fsys.storageClient()
+	if err != nil {
+		return nil, &fs.PathError{Op: "OpenRange", Path: name, Err: err}
+	}
+	r, err := client.Bucket(fsys.bucket).Object(name).NewRangeReader(fsys.Context(), offset, length)
+	if err != nil {
+		return nil, &fs.PathError{Op: "OpenRange", Path: name, Err: err}
+	}
+	return r, nil
+}
+
+// Close closes the clients.
+func (fsys *gcsFS) Close() error {
+	err := fsys.GCSFS.Close()
+	fsys.mutex.Lock()
+	defer fsys.mutex.Unlock()
+	if fsys.client != nil {
+		if e := fsys.client.Close(); e != nil && err == nil {
+			err = e
+		}
+		fsys.client = nil
+	}
+	return err
+}
+
+func gcsMetadata(attrs *storage.ObjectAttrs) map[string]any {
+	metadata := attrs.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	m := map[string]any{
+		"etag":           attrs.Etag,
+		"storageClass":   attrs.StorageClass,
+		"contentType":    attrs.ContentType,
+		"metadata":       metadata,
+		"generation":     attrs.Generation,
+		"metageneration": attrs.Metageneration,
+		"crc32c":         attrs.CRC32C,
+	}
+	if attrs.ContentEncoding != "" {
+		m["contentEncoding"] = attrs.ContentEncoding
+	}
+	if len(attrs.MD5) > 0 {
+		m["md5"] = hex.EncodeToString(attrs.MD5)
+	}
+	return m
+}
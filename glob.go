@@ -0,0 +1,109 @@
+package fssh
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Glob returns the names of files matching the pattern like a POSIX shell.
+// Each segment of the pattern is expanded in its directory and "*" does not match names starting
+// with "." unless the segment starts with ".". Like fs.Glob errors reading directories are ignored.
+func Glob(fsys fs.FS, pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	segments := strings.Split(pattern, "/")
+	names := []string{""}
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		var nexts []string
+		for _, dir := range names {
+			if !IsGlobPattern(segment) {
+				name := path.Join(dir, segment)
+				if last {
+					if _, err := fs.Stat(fsys, name); err != nil {
+						continue
+					}
+				}
+				nexts = append(nexts, name)
+				continue
+			}
+			readDir := dir
+			if readDir == "" {
+				readDir = "."
+			}
+			entries, err := fs.ReadDir(fsys, readDir)
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				if strings.HasPrefix(entry.Name(), ".") && !strings.HasPrefix(segment, ".") {
+					continue
+				}
+				if !last && !entry.IsDir() {
+					continue
+				}
+				if ok, _ := path.Match(segment, entry.Name()); ok {
+					nexts = append(nexts, path.Join(dir, entry.Name()))
+				}
+			}
+		}
+		names = nexts
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ExpandGlob returns URLs of files matching the filenameUrl if it is a glob pattern otherwise
+// returns the filenameUrl as is. A file whose name is the filenameUrl such as "report[1].log" is
+// returned as is even if the name is a glob pattern. The glob segments of the filenameUrl are
+// replaced with the matched names so the URLs are relative to the current directory like the filenameUrl.
+func (sh *Shell) ExpandGlob(filenameUrl string) ([]string, error) {
+	fsys, name, err := sh.SubFS(filenameUrl)
+	if err != nil {
+		return nil, err
+	}
+	if !IsGlobPattern(name) {
+		return []string{filenameUrl}, nil
+	}
+	if _, err := fs.Stat(fsys, name); err == nil {
+		return []string{filenameUrl}, nil
+	}
+	matches, err := Glob(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no matches found: %s", filenameUrl)
+	}
+	urls := make([]string, len(matches))
+	for i, match := range matches {
+		urls[i] = globURL(filenameUrl, name, match)
+	}
+	return urls, nil
+}
+
+// globURL returns the filenameUrl that the glob segments are replaced with the segments of the match.
+// The pattern is the name of the filenameUrl in the FS and the match is a name matching the pattern.
+func globURL(filenameUrl, pattern, match string) string {
+	urlSegments := strings.Split(filenameUrl, "/")
+	patternSegments := strings.Split(pattern, "/")
+	u := firstGlobSegment(urlSegments)
+	p := firstGlobSegment(patternSegments)
+	if u < 0 || p < 0 || len(urlSegments)-u != len(patternSegments)-p {
+		return match
+	}
+	return strings.Join(append(urlSegments[:u:u], strings.Split(match, "/")[p:]...), "/")
+}
+
+func firstGlobSegment(segments []string) int {
+	for i, segment := range segments {
+		if IsGlobPattern(segment) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,141 @@
+package fssh
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGlob(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt":         {},
+		"b.csv":         {},
+		".hidden.txt":   {},
+		"dir/c.txt":     {},
+		"dir/.d.txt":    {},
+		"dir/sub/e.txt": {},
+		"dir2/f.txt":    {},
+		".git/g.txt":    {},
+	}
+	tests := []struct {
+		pattern string
+		want    []string
+		errstr  string
+	}{
+		{
+			pattern: "*.txt",
+			want:    []string{"a.txt"},
+		}, {
+			pattern: ".*.txt",
+			want:    []string{".hidden.txt"},
+		}, {
+			pattern: "*/*.txt",
+			want:    []string{"dir/c.txt", "dir2/f.txt"},
+		}, {
+			pattern: "dir/.*",
+			want:    []string{"dir/.d.txt"},
+		}, {
+			pattern: "dir*",
+			want:    []string{"dir", "dir2"},
+		}, {
+			pattern: "dir/*/e.txt",
+			want:    []string{"dir/sub/e.txt"},
+		}, {
+			pattern: "dir/sub/*",
+			want:    []string{"dir/sub/e.txt"},
+		}, {
+			pattern: "a.txt/*",
+		}, {
+			pattern: "none/*",
+		}, {
+			pattern: "[",
+			errstr:  "syntax error in pattern",
+		},
+	}
+	for i, test := range tests {
+		got, err := Glob(fsys, test.pattern)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d]: no error; want %s", i, test.errstr)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d]: got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestShellExpandGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.csv", "sub/d.txt", "report[1].log", "report1.log"} {
+		name = filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sh, err := NewScriptShell(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		filenameUrl string
+		want        []string
+		errstr      string
+	}{
+		{
+			filenameUrl: "*.txt",
+			want:        []string{"a.txt", "b.txt"},
+		}, {
+			filenameUrl: "sub/*.txt",
+			want:        []string{"sub/d.txt"},
+		}, {
+			filenameUrl: "*/d.txt",
+			want:        []string{"sub/d.txt"},
+		}, {
+			filenameUrl: "./*.csv",
+			want:        []string{"./c.csv"},
+		}, {
+			filenameUrl: "none.txt",
+			want:        []string{"none.txt"},
+		}, {
+			filenameUrl: "report[1].log",
+			want:        []string{"report[1].log"},
+		}, {
+			filenameUrl: "report[0-9].log",
+			want:        []string{"report1.log"},
+		}, {
+			filenameUrl: "*.json",
+			errstr:      "no matches found: *.json",
+		},
+	}
+	for i, test := range tests {
+		got, err := sh.ExpandGlob(test.filenameUrl)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d]: no error; want %s", i, test.errstr)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d]: got %v; want %v", i, got, test.want)
+		}
+	}
+}
@@ -3,12 +3,19 @@ module github.com/jarxorg/fssh
 go 1.20
 
 require (
+	cloud.google.com/go/storage v1.33.0
+	github.com/BurntSushi/toml v1.3.2
+	github.com/aws/aws-sdk-go v1.45.15
 	github.com/chzyer/readline v1.5.1
 	github.com/gobs/args v0.0.0-20210311043657-b8c0b223be93
 	github.com/jarxorg/gcsfs v0.1.5
 	github.com/jarxorg/s3fs v0.2.2
 	github.com/jarxorg/wfs v0.3.2
+	github.com/klauspost/compress v1.16.7
+	github.com/pkg/sftp v1.13.6
+	golang.org/x/crypto v0.13.0
 	golang.org/x/exp v0.0.0-20220827204233-334a2380cb91
+	golang.org/x/net v0.15.0
 )
 
 require (
@@ -16,8 +23,6 @@ require (
 	cloud.google.com/go/compute v1.23.0 // indirect
 	cloud.google.com/go/compute/metadata v0.2.3 // indirect
 	cloud.google.com/go/iam v1.1.1 // indirect
-	cloud.google.com/go/storage v1.33.0 // indirect
-	github.com/aws/aws-sdk-go v1.45.15 // indirect
 	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
 	github.com/golang/protobuf v1.5.3 // indirect
 	github.com/google/go-cmp v0.5.9 // indirect
@@ -27,9 +32,8 @@ require (
 	github.com/googleapis/gax-go/v2 v2.12.0 // indirect
 	github.com/jarxorg/io2 v0.7.1 // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
+	github.com/kr/fs v0.1.0 // indirect
 	go.opencensus.io v0.24.0 // indirect
-	golang.org/x/crypto v0.13.0 // indirect
-	golang.org/x/net v0.15.0 // indirect
 	golang.org/x/oauth2 v0.12.0 // indirect
 	golang.org/x/sync v0.3.0 // indirect
 	golang.org/x/sys v0.12.0 // indirect
@@ -0,0 +1,307 @@
+package fssh
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/jarxorg/wfs"
+	"golang.org/x/net/html"
+)
+
+// httpFS is a read-only file system of a HTTP server. Directories are read from HTML listings
+// such as autoindex of nginx and Apache.
+type httpFS struct {
+	baseURL string
+	client  *http.Client
+}
+
+var (
+	_ fs.ReadDirFS     = (*httpFS)(nil)
+	_ fs.StatFS        = (*httpFS)(nil)
+	_ wfs.RemoveFileFS = (*httpFS)(nil)
+	_ RangeReaderFS    = (*httpFS)(nil)
+)
+
+// newHTTPFS returns a file system of the baseURL such as "https://example.com".
+func newHTTPFS(baseURL string) *httpFS {
+	return &httpFS{
+		baseURL: baseURL,
+		client:  http.DefaultClient,
+	}
+}
+
+// url returns the URL of the name. The URL of a directory has the trailing slash.
+func (fsys *httpFS) url(name string, isDir bool) string {
+	p := "/"
+	if name != "." {
+		p += name
+		if isDir {
+			p += "/"
+		}
+	}
+	return fsys.baseURL + (&url.URL{Path: p}).EscapedPath()
+}
+
+// get sends the request of the method and returns the successful response.
+// If the rng is not empty then this sets it to the Range header.
+func (fsys *httpFS) get(op, name, method, rawURL, rng string) (*http.Response, error) {
+	req, err := http.NewRequest(method, rawURL, nil)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	if rng != "" {
+		req.Header.Set("Range", rng)
+	}
+	res, err := fsys.client.Do(req)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		res.Body.Close()
+		return nil, &fs.PathError{Op: op, Path: name, Err: httpStatusError(res)}
+	}
+	return res, nil
+}
+
+func httpStatusError(res *http.Response) error {
+	switch res.StatusCode {
+	case http.StatusNotFound, http.StatusGone:
+		return fs.ErrNotExist
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fs.ErrPermission
+	}
+	return fmt.Errorf("%s %s: %s", res.Request.Method, res.Request.URL, res.Status)
+}
+
+// newHTTPFileInfo returns the FileInfo of the response. The name is a directory if the server
+// redirected to the URL with the trailing slash like autoindex of nginx and Apache.
+// The Sys() of the FileInfo returns http.Header.
+func newHTTPFileInfo(name string, res *http.Response) *objectInfo {
+	info := &objectInfo{
+		name:  path.Base(name),
+		isDir: strings.HasSuffix(res.Request.URL.Path, "/"),
+		sys:   res.Header,
+	}
+	if !info.isDir && res.ContentLength > 0 {
+		info.size = res.ContentLength
+	}
+	if modTime, err := http.ParseTime(res.Header.Get("Last-Modified")); err == nil {
+		info.modTime = modTime
+	}
+	return info
+}
+
+// Open opens the named file.
+func (fsys *httpFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return &dirFile{fsys: fsys, name: name, info: &objectInfo{name: name, isDir: true}}, nil
+	}
+	res, err := fsys.get("open", name, http.MethodGet, fsys.url(name, false), "")
+	if err != nil {
+		return nil, err
+	}
+	info := newHTTPFileInfo(name, res)
+	if info.IsDir() {
+		res.Body.Close()
+		return &dirFile{fsys: fsys, name: name, info: info}, nil
+	}
+	return &readerFile{ReadCloser: res.Body, info: info}, nil
+}
+
+// ReadDir reads the HTML listing of the named directory and returns a list of linked entries
+// sorted by filename.
+func (fsys *httpFS) ReadDir(dir string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(dir) {
+		return nil, &fs.PathError{Op: "readdir", Path: dir, Err: fs.ErrInvalid}
+	}
+	res, err := fsys.get("readdir", dir, http.MethodGet, fsys.url(dir, true), "")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	links, err := parseHTTPListing(res.Request.URL, res.Body)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: dir, Err: err}
+	}
+	entries := make([]fs.DirEntry, 0, len(links))
+	for childName, isDir := range links {
+		entries = append(entries, &httpDirEntry{fsys: fsys, name: path.Join(dir, childName), isDir: isDir})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// parseHTTPListing returns names of children of the base URL that are linked from the HTML
+// and whether each child is a directory. Links to parents, other hosts and queries are ignored.
+func parseHTTPListing(base *url.URL, r io.Reader) (map[string]bool, error) {
+	links := map[string]bool{}
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return links, nil
+			}
+			return nil, z.Err()
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tag, hasAttr := z.TagName()
+			if string(tag) != "a" {
+				continue
+			}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) != "href" {
+					continue
+				}
+				if name, isDir, ok := httpChildName(base, string(val)); ok {
+					links[name] = isDir
+				}
+			}
+		}
+	}
+}
+
+// httpChildName returns the name of the href if it refers a child of the base URL.
+func httpChildName(base *url.URL, href string) (name string, isDir bool, ok bool) {
+	u, err := base.Parse(href)
+	if err != nil || u.Scheme != base.Scheme || u.Host != base.Host || u.RawQuery != "" {
+		return "", false, false
+	}
+	dir := base.Path
+	if !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+	if !strings.HasPrefix(u.Path, dir) {
+		return "", false, false
+	}
+	name = strings.TrimPrefix(u.Path, dir)
+	isDir = strings.HasSuffix(name, "/")
+	name = strings.TrimSuffix(name, "/")
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		return "", false, false
+	}
+	return name, isDir, true
+}
+
+// Stat returns a FileInfo describing the file using the HEAD request.
+func (fsys *httpFS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return &objectInfo{name: name, isDir: true}, nil
+	}
+	res, err := fsys.get("stat", name, http.MethodHead, fsys.url(name, false), "")
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+	return newHTTPFileInfo(name, res), nil
+}
+
+// OpenRange opens the named file to read length bytes from offset using the Range header.
+func (fsys *httpFS) OpenRange(name string, offset, length int64) (io.ReadCloser, error) {
+	if length == 0 {
+		return io.NopCloser(&io.LimitedReader{}), nil
+	}
+	rng := fmt.Sprintf("bytes=%d-", offset)
+	if length > 0 {
+		rng = fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	}
+	res, err := fsys.get("openrange", name, http.MethodGet, fsys.url(name, false), rng)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusPartialContent {
+		return res.Body, nil
+	}
+	// NOTE: The server ignored the Range header and returned the whole content.
+	if _, err := io.CopyN(io.Discard, res.Body, offset); err != nil && err != io.EOF {
+		res.Body.Close()
+		return nil, &fs.PathError{Op: "openrange", Path: name, Err: err}
+	}
+	if length < 0 {
+		return res.Body, nil
+	}
+	return &limitedReadCloser{Reader: io.LimitReader(res.Body, length), Closer: res.Body}, nil
+}
+
+// MkdirAll returns ErrReadOnly.
+func (fsys *httpFS) MkdirAll(dir string, mode fs.FileMode) error {
+	return &fs.PathError{Op: "mkdir", Path: dir, Err: ErrReadOnly}
+}
+
+// CreateFile returns ErrReadOnly.
+func (fsys *httpFS) CreateFile(name string, mode fs.FileMode) (wfs.WriterFile, error) {
+	return nil, &fs.PathError{Op: "create", Path: name, Err: ErrReadOnly}
+}
+
+// WriteFile returns ErrReadOnly.
+func (fsys *httpFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	return 0, &fs.PathError{Op: "write", Path: name, Err: ErrReadOnly}
+}
+
+// RemoveFile returns ErrReadOnly.
+func (fsys *httpFS) RemoveFile(name string) error {
+	return &fs.PathError{Op: "remove", Path: name, Err: ErrReadOnly}
+}
+
+// RemoveAll returns ErrReadOnly.
+func (fsys *httpFS) RemoveAll(name string) error {
+	return &fs.PathError{Op: "remove", Path: name, Err: ErrReadOnly}
+}
+
+// httpDirEntry is an entry of the HTML listing. The Info sends the HEAD request of the file.
+type httpDirEntry struct {
+	fsys  *httpFS
+	name  string
+	isDir bool
+}
+
+func (e *httpDirEntry) Name() string {
+	return path.Base(e.name)
+}
+
+func (e *httpDirEntry) IsDir() bool {
+	return e.isDir
+}
+
+func (e *httpDirEntry) Type() fs.FileMode {
+	if e.isDir {
+		return fs.ModeDir
+	}
+	return 0
+}
+
+func (e *httpDirEntry) Info() (fs.FileInfo, error) {
+	if e.isDir {
+		return &objectInfo{name: e.Name(), isDir: true}, nil
+	}
+	return e.fsys.Stat(e.name)
+}
+
+func httpMetadata(header http.Header) map[string]any {
+	m := map[string]any{
+		"contentType": header.Get("Content-Type"),
+	}
+	if etag := header.Get("ETag"); etag != "" {
+		m["etag"] = etag
+	}
+	if contentEncoding := header.Get("Content-Encoding"); contentEncoding != "" {
+		m["contentEncoding"] = contentEncoding
+	}
+	return m
+}
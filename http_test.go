@@ -0,0 +1,173 @@
+package fssh
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func newTestHTTPServer(t *testing.T) *httptest.Server {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"a.txt":         {Data: []byte("abcdef"), ModTime: modTime},
+		"dir/b.txt":     {Data: []byte("b"), ModTime: modTime},
+		"dir/sub/c.txt": {Data: []byte("c"), ModTime: modTime},
+	}
+	s := httptest.NewServer(http.FileServer(http.FS(fsys)))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestParseHTTPListing(t *testing.T) {
+	base, err := url.Parse("https://example.com/dir/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		html string
+		want map[string]bool
+	}{
+		{
+			// NOTE: http.FileServer
+			html: `<pre>
+<a href="a.txt">a.txt</a>
+<a href="sub/">sub/</a>
+</pre>`,
+			want: map[string]bool{"a.txt": false, "sub": true},
+		}, {
+			// NOTE: nginx autoindex
+			html: `<html><head><title>Index of /dir/</title></head><body><h1>Index of /dir/</h1><hr><pre>
+<a href="../">../</a>
+<a href="a%20b.txt">a b.txt</a>                                          02-Jan-2024 03:04       6
+<a href="sub/">sub/</a>                                               02-Jan-2024 03:04       -
+</pre><hr></body></html>`,
+			want: map[string]bool{"a b.txt": false, "sub": true},
+		}, {
+			// NOTE: Apache mod_autoindex
+			html: `<table>
+<tr><th><a href="?C=N;O=D">Name</a></th><th><a href="?C=M;O=A">Last modified</a></th></tr>
+<tr><td><a href="/">Parent Directory</a></td></tr>
+<tr><td><a href="/dir/a.txt">a.txt</a></td></tr>
+<tr><td><a href="https://example.com/dir/sub/">sub/</a></td></tr>
+<tr><td><a href="https://other.example.com/dir/x.txt">x.txt</a></td></tr>
+<tr><td><a href="sub/c.txt">c.txt</a></td></tr>
+<tr><td><a href="#top">top</a></td></tr>
+</table>`,
+			want: map[string]bool{"a.txt": false, "sub": true},
+		}, {
+			html: `<p>no links</p>`,
+			want: map[string]bool{},
+		},
+	}
+	for i, test := range tests {
+		got, err := parseHTTPListing(base, strings.NewReader(test.html))
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d]: got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestHTTPFS(t *testing.T) {
+	s := newTestHTTPServer(t)
+	fsys, _, _, _, err := NewFS(s.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(fsys, "a.txt", "dir/b.txt", "dir/sub/c.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := fs.Stat(fsys, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 6 || info.IsDir() {
+		t.Errorf("got size %d, isDir %v; want 6, false", info.Size(), info.IsDir())
+	}
+	info, err = fs.Stat(fsys, "dir/sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.IsDir() {
+		t.Errorf("dir/sub is not a directory")
+	}
+	if _, err := fs.Stat(fsys, "none.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got err %v; want %v", err, fs.ErrNotExist)
+	}
+
+	r, err := OpenRange(fsys, "a.txt", 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "cde" {
+		t.Errorf("got %q; want %q", got, "cde")
+	}
+}
+
+func TestHTTPFSOpenRangeIgnored(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "abcdef")
+	}))
+	defer s.Close()
+
+	fsys := newHTTPFS(s.URL)
+	tests := []struct {
+		offset int64
+		length int64
+		want   string
+	}{
+		{offset: 2, length: 3, want: "cde"},
+		{offset: 4, length: -1, want: "ef"},
+		{offset: 10, length: -1, want: ""},
+	}
+	for i, test := range tests {
+		r, err := fsys.OpenRange("a.txt", test.offset, test.length)
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		got, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if string(got) != test.want {
+			t.Errorf("tests[%d]: got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestHTTPFSReadOnly(t *testing.T) {
+	fsys := newHTTPFS("https://example.com")
+	errs := []error{
+		fsys.MkdirAll("dir", os.ModePerm),
+		fsys.RemoveFile("a.txt"),
+		fsys.RemoveAll("dir"),
+	}
+	_, err := fsys.CreateFile("a.txt", os.ModePerm)
+	errs = append(errs, err)
+	_, err = fsys.WriteFile("a.txt", []byte("a"), os.ModePerm)
+	errs = append(errs, err)
+	for i, err := range errs {
+		if !errors.Is(err, ErrReadOnly) {
+			t.Errorf("errs[%d]: got %v; want %v", i, err, ErrReadOnly)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package fssh
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// JournalEntry represents a file completely transferred.
+type JournalEntry struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	MD5    string `json:"md5"`
+	CRC32C uint32 `json:"crc32c"`
+	SHA256 string `json:"sha256"`
+}
+
+// NewJournalEntry returns the entry of the named file that has the checksum.
+func NewJournalEntry(name string, size int64, sum *Checksum) JournalEntry {
+	return JournalEntry{
+		Name:   name,
+		Size:   size,
+		MD5:    hex.EncodeToString(sum.MD5),
+		CRC32C: sum.CRC32C,
+		SHA256: hex.EncodeToString(sum.SHA256),
+	}
+}
+
+// Checksum returns the checksum of the entry. Entries recorded without checksums return an error.
+func (e JournalEntry) Checksum() (*Checksum, error) {
+	md5Sum, err := hex.DecodeString(e.MD5)
+	if err != nil {
+		return nil, err
+	}
+	sha256Sum, err := hex.DecodeString(e.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	if len(md5Sum) == 0 || len(sha256Sum) == 0 {
+		return nil, fmt.Errorf("no checksum of %s", e.Name)
+	}
+	return &Checksum{MD5: md5Sum, CRC32C: e.CRC32C, SHA256: sha256Sum}, nil
+}
+
+// Journal records files completely transferred so that an interrupted transfer can be resumed.
+// Journals are stored in ".fssh_journal" in the home directory.
+type Journal struct {
+	mutex   sync.Mutex
+	path    string
+	file    *os.File
+	entries map[string]JournalEntry
+}
+
+// JournalPath returns the path of the journal for the transfer from fromUrl to toUrl.
+func JournalPath(fromUrl, toUrl string) (string, error) {
+	homeDir, err := osUserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(fromUrl + "\n" + toUrl))
+	name := hex.EncodeToString(sum[:]) + ".jsonl"
+	return filepath.Join(homeDir, fmt.Sprintf(".%s_journal", ShellName), name), nil
+}
+
+// OpenJournal opens the journal for the transfer from fromUrl to toUrl.
+// If resume is true then this loads entries recorded by the previous transfer otherwise the journal is truncated.
+func OpenJournal(fromUrl, toUrl string, resume bool) (*Journal, error) {
+	path, err := JournalPath(fromUrl, toUrl)
+	if err != nil {
+		return nil, err
+	}
+	j := &Journal{
+		path:    path,
+		entries: map[string]JournalEntry{},
+	}
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if resume {
+		if err := j.load(); err != nil {
+			return nil, err
+		}
+		flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	j.file, err = os.OpenFile(path, flag, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func (j *Journal) load() error {
+	f, err := os.Open(j.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e JournalEntry
+		// NOTE: Ignore a broken line that may be written when the transfer was interrupted.
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		j.entries[e.Name] = e
+	}
+	return scanner.Err()
+}
+
+// Lookup returns the entry of the name recorded by the previous transfer.
+func (j *Journal) Lookup(name string) (JournalEntry, bool) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	e, ok := j.entries[name]
+	return e, ok
+}
+
+// HasDir reports whether the journal has entries in the dir.
+func (j *Journal) HasDir(dir string) bool {
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	for name := range j.entries {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Record records the entry.
+func (j *Journal) Record(e JournalEntry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.entries[e.Name] = e
+	_, err = j.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the journal.
+func (j *Journal) Close() error {
+	return j.file.Close()
+}
+
+// Remove closes and removes the journal. This should be called when the transfer completes.
+func (j *Journal) Remove() error {
+	if err := j.Close(); err != nil {
+		return err
+	}
+	return os.Remove(j.path)
+}
@@ -0,0 +1,76 @@
+package fssh
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	done := setupTestNewShell(t)
+	defer done()
+
+	j, err := OpenJournal("from", "to", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewChecksumWriter()
+	w.Write([]byte("test"))
+	sum := w.Checksum()
+	want := NewJournalEntry("dir/test.txt", 4, sum)
+	if want.MD5 != "098f6bcd4621d373cade4e832627b4f6" {
+		t.Errorf("got md5 %s; want %s", want.MD5, "098f6bcd4621d373cade4e832627b4f6")
+	}
+	if got, err := want.Checksum(); err != nil || !reflect.DeepEqual(got, sum) {
+		t.Errorf("got checksum %v, %v; want %v", got, err, sum)
+	}
+	if _, err := (JournalEntry{Name: "old.txt", Size: 4, MD5: want.MD5}).Checksum(); err == nil {
+		t.Errorf("no error of the entry without sha256")
+	}
+	if err := j.Record(want); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		resume bool
+		wantOK bool
+	}{
+		{resume: true, wantOK: true},
+		{resume: false, wantOK: false},
+	}
+	for i, test := range tests {
+		j, err := OpenJournal("from", "to", test.resume)
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		got, ok := j.Lookup(want.Name)
+		if ok != test.wantOK {
+			t.Errorf("tests[%d]: got ok %v; want %v", i, ok, test.wantOK)
+		}
+		if ok && !reflect.DeepEqual(got, want) {
+			t.Errorf("tests[%d]: got %v; want %v", i, got, want)
+		}
+		if got := j.HasDir("dir"); got != test.wantOK {
+			t.Errorf("tests[%d]: got HasDir %v; want %v", i, got, test.wantOK)
+		}
+		j.Close()
+	}
+
+	j, err = OpenJournal("from", "to", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	path, err := JournalPath("from", "to")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("got err %v; want not exist", err)
+	}
+}
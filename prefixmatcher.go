@@ -78,7 +78,7 @@ func (m *GlobPrefixMatcher) matches(sh *Shell, prefix string, flgs int) ([]strin
 		return nil, err
 	}
 	if flgs&flgGlobPrefixFiles != 0 && flgs&flgGlobPrefixDirs != 0 {
-		return m.normalizeMatches(sh, prefix, matches)
+		return m.normalizeMatches(sh, prefix, matches, true)
 	}
 	if flgs&flgGlobPrefixFiles != 0 {
 		var files []string
@@ -91,7 +91,7 @@ func (m *GlobPrefixMatcher) matches(sh *Shell, prefix string, flgs int) ([]strin
 				files = append(files, match)
 			}
 		}
-		return m.normalizeMatches(sh, prefix, files)
+		return m.normalizeMatches(sh, prefix, files, false)
 	}
 	var dirs []string
 	for _, match := range matches {
@@ -103,7 +103,7 @@ func (m *GlobPrefixMatcher) matches(sh *Shell, prefix string, flgs int) ([]strin
 			dirs = append(dirs, match)
 		}
 	}
-	return m.normalizeMatches(sh, prefix, dirs)
+	return m.normalizeMatches(sh, prefix, dirs, true)
 }
 
 func (m *GlobPrefixMatcher) prefixSubFS(sh *Shell, prefix string) (FS, string, error) {
@@ -113,14 +113,16 @@ func (m *GlobPrefixMatcher) prefixSubFS(sh *Shell, prefix string) (FS, string, e
 		}
 		return sh.FS, path.Join(sh.Dir, prefix+"*"), nil
 	}
-	fsys, _, _, dir, err := NewFS(prefix)
+	fsys, _, _, dir, err := sh.NewFS(sh.ResolveArchivePath(prefix))
 	if err != nil {
 		return nil, "", err
 	}
 	return fsys, dir + "*", nil
 }
 
-func (m *GlobPrefixMatcher) normalizeMatches(sh *Shell, prefix string, matches []string) ([]string, error) {
+// normalizeMatches returns matches relative to the current directory. If the withRemotes is true
+// then names of remotes such as "prod:/" that match the prefix are added.
+func (m *GlobPrefixMatcher) normalizeMatches(sh *Shell, prefix string, matches []string, withRemotes bool) ([]string, error) {
 	if IsCurrentPath(prefix) {
 		for i := range matches {
 			rel, err := filepath.Rel(sh.Dir, matches[i])
@@ -133,6 +135,17 @@ func (m *GlobPrefixMatcher) normalizeMatches(sh *Shell, prefix string, matches [
 			matches[i] = rel
 		}
 	}
+	if withRemotes && !strings.Contains(prefix, "/") {
+		c, err := LoadConfig()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range c.RemoteNames() {
+			if remote := name + ":/"; strings.HasPrefix(remote, prefix) {
+				matches = append(matches, remote)
+			}
+		}
+	}
 	m.cachePrefix = prefix
 	m.cachePrefixMatches = matches
 	return SliceClone(matches), nil
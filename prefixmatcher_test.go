@@ -0,0 +1,66 @@
+package fssh
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/jarxorg/wfs/memfs"
+)
+
+func TestGlobPrefixMatcherRemotes(t *testing.T) {
+	setTestConfig(t, ".fsshrc", `
+[remotes.prod]
+protocol = "s3"
+bucket = "bucket"
+
+[remotes.stg]
+protocol = "s3"
+bucket = "bucket-stg"
+`)
+	fsys := memfs.New()
+	if err := fsys.MkdirAll("proj", os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.WriteFile("prod.txt", []byte("prod"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	sh := &Shell{FS: fsys, Dir: "."}
+
+	tests := []struct {
+		match  func(m PrefixMatcher, sh *Shell, prefix string) ([]string, error)
+		prefix string
+		want   []string
+	}{
+		{
+			match:  PrefixMatcher.Matches,
+			prefix: "pr",
+			want:   []string{"prod.txt", "proj", "prod:/"},
+		}, {
+			match:  PrefixMatcher.MatchDirs,
+			prefix: "pr",
+			want:   []string{"proj", "prod:/"},
+		}, {
+			match:  PrefixMatcher.MatchFiles,
+			prefix: "pr",
+			want:   []string{"prod.txt"},
+		}, {
+			match:  PrefixMatcher.MatchDirs,
+			prefix: "",
+			want:   []string{"proj", "prod:/", "stg:/"},
+		}, {
+			match:  PrefixMatcher.MatchDirs,
+			prefix: "./pr",
+			want:   []string{"./proj"},
+		},
+	}
+	for i, test := range tests {
+		got, err := test.match(&GlobPrefixMatcher{}, sh, test.prefix)
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("tests[%d]: got %v; want %v", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package fssh
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// progressInterval is the interval of drawing the progress.
+const progressInterval = 500 * time.Millisecond
+
+// Progress draws the progress of a transfer such as bytes, files, throughput and ETA.
+// All methods of a nil Progress do nothing so commands can use it without checking whether it is enabled.
+type Progress struct {
+	w          io.Writer
+	mutex      sync.Mutex
+	start      time.Time
+	bytes      atomic.Int64
+	files      atomic.Int64
+	totalBytes atomic.Int64
+	totalFiles atomic.Int64
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+var _ io.Writer = (*Progress)(nil)
+
+// NewProgress starts drawing a progress on the stderr of the shell.
+// If the shell is not interactive or the stderr is not a terminal then this returns nil.
+func (sh *Shell) NewProgress() *Progress {
+	if !sh.IsTerminal() {
+		return nil
+	}
+	return newProgress(sh.Stderr, progressInterval)
+}
+
+func newProgress(w io.Writer, interval time.Duration) *Progress {
+	p := &Progress{
+		w:     w,
+		start: time.Now(),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go p.run(interval)
+	return p
+}
+
+func (p *Progress) run(interval time.Duration) {
+	defer close(p.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			// NOTE: Clear the line so that the prompt is drawn at the beginning of the line.
+			p.mutex.Lock()
+			fmt.Fprint(p.w, "\r\033[K")
+			p.mutex.Unlock()
+			return
+		case <-ticker.C:
+			p.mutex.Lock()
+			fmt.Fprintf(p.w, "\r\033[K%s", p)
+			p.mutex.Unlock()
+		}
+	}
+}
+
+// AddTotal adds the number of files and bytes to transfer.
+func (p *Progress) AddTotal(files int, bytes int64) {
+	if p == nil {
+		return
+	}
+	p.totalFiles.Add(int64(files))
+	p.totalBytes.Add(bytes)
+}
+
+// Write counts transferred bytes. This is used with io.TeeReader or io.MultiWriter.
+func (p *Progress) Write(b []byte) (int, error) {
+	if p != nil {
+		p.bytes.Add(int64(len(b)))
+	}
+	return len(b), nil
+}
+
+// DoneFile counts a transferred file.
+func (p *Progress) DoneFile() {
+	if p == nil {
+		return
+	}
+	p.files.Add(1)
+}
+
+// SkipFile removes the skipped file from the total.
+func (p *Progress) SkipFile(bytes int64) {
+	p.AddTotal(-1, -bytes)
+}
+
+// Printf clears the progress line and writes the message to w. The progress is drawn again at the next interval.
+func (p *Progress) Printf(w io.Writer, format string, a ...any) {
+	if p == nil {
+		fmt.Fprintf(w, format, a...)
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	fmt.Fprint(p.w, "\r\033[K")
+	fmt.Fprintf(w, format, a...)
+}
+
+// Stop stops drawing the progress and clears the line.
+func (p *Progress) Stop() {
+	if p == nil {
+		return
+	}
+	close(p.stop)
+	<-p.done
+}
+
+// String returns the progress such as "10M / 20M, 1 / 2 files, 5M/s, ETA 2s".
+func (p *Progress) String() string {
+	bytes, totalBytes := p.bytes.Load(), p.totalBytes.Load()
+	rate := int64(0)
+	if elapsed := time.Since(p.start).Seconds(); elapsed > 0 {
+		rate = int64(float64(bytes) / elapsed)
+	}
+	s := fmt.Sprintf("%s / %s, %d / %d files, %s/s",
+		strings.TrimSpace(DisplaySize(bytes)), strings.TrimSpace(DisplaySize(totalBytes)),
+		p.files.Load(), p.totalFiles.Load(), strings.TrimSpace(DisplaySize(rate)))
+	if rate > 0 && totalBytes > bytes {
+		eta := time.Duration(float64(totalBytes-bytes) / float64(rate) * float64(time.Second))
+		s += ", ETA " + eta.Round(time.Second).String()
+	}
+	return s
+}
@@ -0,0 +1,125 @@
+package fssh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testSyncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (b *testSyncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *testSyncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
+
+func TestProgress(t *testing.T) {
+	w := &testSyncBuffer{}
+	p := newProgress(w, time.Millisecond)
+	p.AddTotal(3, 3072)
+	p.Write(make([]byte, 1024))
+	p.DoneFile()
+	p.SkipFile(1024)
+	out := &testSyncBuffer{}
+	p.Printf(out, "add: %s\n", "test.txt")
+	time.Sleep(10 * time.Millisecond)
+	p.Stop()
+
+	got := w.String()
+	if !strings.Contains(got, "\r\033[K1K / 2K, 1 / 2 files, ") {
+		t.Errorf("got %q; want the progress", got)
+	}
+	if !strings.Contains(got, ", ETA ") {
+		t.Errorf("got %q; want ETA", got)
+	}
+	if got := out.String(); got != "add: test.txt\n" {
+		t.Errorf("got %q; want the message", got)
+	}
+	if !strings.HasSuffix(got, "\r\033[K") {
+		t.Errorf("got %q; want the cleared line", got)
+	}
+}
+
+func TestNilProgress(t *testing.T) {
+	var p *Progress
+	p.AddTotal(1, 1)
+	p.DoneFile()
+	p.SkipFile(1)
+	w := &bytes.Buffer{}
+	p.Printf(w, "%d", 1)
+	if got := w.String(); got != "1" {
+		t.Errorf("got %q; want 1", got)
+	}
+	if n, err := p.Write([]byte("test")); n != 4 || err != nil {
+		t.Errorf("got %d, %v; want 4, nil", n, err)
+	}
+	p.Stop()
+}
+
+func TestShellNewProgress(t *testing.T) {
+	sh, err := NewScriptShell("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := sh.NewProgress(); p != nil {
+		t.Errorf("got %v; want nil in script mode", p)
+	}
+}
+
+func TestShellNewProgressRedirect(t *testing.T) {
+	done := setupTestNewShell(t)
+	defer done()
+	isTerminalOrg := isTerminal
+	isTerminal = func(fd int) bool { return true }
+	defer func() { isTerminal = isTerminalOrg }()
+
+	RegisterNewCommandFunc(func() Command {
+		return &testCommand{
+			name: "progress",
+			execFunc: func(ctx context.Context, sh *Shell) error {
+				p := sh.NewProgress()
+				if p == nil {
+					return errors.New("no progress")
+				}
+				p.Stop()
+				return nil
+			},
+		}
+	})
+	defer DeregisterNewCommandFunc("progress")
+
+	sh, err := NewShell("mem://")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sh.Close()
+	if !sh.IsTerminal() {
+		t.Fatal("got false; want true on the terminal")
+	}
+	err = sh.ExecRedirect(context.Background(), "progress 2> log.txt")
+	if err == nil || err.Error() != "no progress" {
+		t.Errorf("got err %v; want no progress", err)
+	}
+	got, err := fs.ReadFile(sh.FS, "log.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got %q; want empty", got)
+	}
+}
@@ -0,0 +1,269 @@
+package fssh
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/jarxorg/s3fs"
+)
+
+// s3FS is s3fs.S3FS that supports ranged reads.
+type s3FS struct {
+	*s3fs.S3FS
+	api    s3iface.S3API
+	bucket string
+}
+
+var _ RangeReaderFS = (*s3FS)(nil)
+
+// S3Options is the options to connect to S3 and S3 compatible storages such as MinIO.
+type S3Options struct {
+	// Endpoint is the URL of the S3 compatible storage such as "http://127.0.0.1:9000".
+	Endpoint string `toml:"endpoint"`
+	// Region is the region of the bucket. If the Endpoint is set then the default is "us-east-1".
+	Region string `toml:"region"`
+	// PathStyle uses URLs like "http://endpoint/bucket/key" instead of "http://bucket.endpoint/key".
+	PathStyle *bool `toml:"path_style"`
+	// InsecureSkipVerify does not verify the certificate of the endpoint.
+	InsecureSkipVerify *bool `toml:"insecure_skip_verify"`
+	// Profile is the profile of the shared credentials like AWS_PROFILE.
+	Profile string `toml:"profile"`
+}
+
+// Merge overrides the options with the values of o that are set. Boolean options set to false
+// override true.
+func (opts *S3Options) Merge(o S3Options) {
+	if o.Endpoint != "" {
+		opts.Endpoint = o.Endpoint
+	}
+	if o.Region != "" {
+		opts.Region = o.Region
+	}
+	if o.PathStyle != nil {
+		opts.PathStyle = o.PathStyle
+	}
+	if o.InsecureSkipVerify != nil {
+		opts.InsecureSkipVerify = o.InsecureSkipVerify
+	}
+	if o.Profile != "" {
+		opts.Profile = o.Profile
+	}
+}
+
+// s3QueryOptions holds options set by URL queries by bucket names so that later URLs of the bucket
+// without queries use the same endpoint. Options of URLs of remotes are held by the remote names
+// such as "prod:" instead. The shell holds it while the session.
+type s3QueryOptions struct {
+	mutex sync.Mutex
+	m     map[string]S3Options
+}
+
+// merge merges the query into the options of the key and returns them.
+// If q is nil then the query is not kept.
+func (q *s3QueryOptions) merge(key string, query S3Options) S3Options {
+	if q == nil {
+		return query
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.m == nil {
+		q.m = map[string]S3Options{}
+	}
+	opts := q.m[key]
+	opts.Merge(query)
+	q.m[key] = opts
+	return opts
+}
+
+// newS3Options returns the options of the bucket. The options are merged in the order of
+// the configuration of all buckets, environment variables such as FSSH_S3_ENDPOINT, the
+// configuration of the bucket, the remote if the rawURL starts with the name of a remote such as
+// "prod:/logs" and the query of the rawURL such as "?endpoint=http://127.0.0.1:9000".
+// Other remotes of the bucket are not used. The queries keeps the query for later URLs of the
+// bucket or the remote.
+func newS3Options(bucket, rawURL string, queries *s3QueryOptions) (S3Options, error) {
+	c, err := LoadConfig()
+	if err != nil {
+		return S3Options{}, err
+	}
+	opts := c.S3.S3Options
+	env, err := parseS3Options(func(key string) (string, bool) {
+		return os.LookupEnv("FSSH_S3_" + strings.ToUpper(key))
+	})
+	if err != nil {
+		return S3Options{}, err
+	}
+	opts.Merge(env)
+	opts.Merge(c.S3.Buckets[bucket])
+	_, remoteName, remote, err := resolveRemote(rawURL)
+	if err != nil {
+		return S3Options{}, err
+	}
+	queryKey := bucket
+	if remoteName != "" {
+		opts.Merge(remote.S3Options)
+		queryKey = remoteName + ":"
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return S3Options{}, err
+	}
+	values := u.Query()
+	query, err := parseS3Options(func(key string) (string, bool) {
+		return values.Get(key), values.Has(key)
+	})
+	if err != nil {
+		return S3Options{}, err
+	}
+	opts.Merge(queries.merge(queryKey, query))
+	return opts, nil
+}
+
+// parseS3Options parses values of keys such as "endpoint" and "path_style" returned by the lookup.
+// Empty values of boolean options are true like "?path_style".
+func parseS3Options(lookup func(key string) (string, bool)) (S3Options, error) {
+	var opts S3Options
+	var errs []error
+	parseBool := func(key string) *bool {
+		v, ok := lookup(key)
+		if !ok {
+			return nil
+		}
+		if v == "" {
+			return aws.Bool(true)
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %q", key, v))
+			return nil
+		}
+		return aws.Bool(b)
+	}
+	opts.Endpoint, _ = lookup("endpoint")
+	opts.Region, _ = lookup("region")
+	opts.Profile, _ = lookup("profile")
+	opts.PathStyle = parseBool("path_style")
+	opts.InsecureSkipVerify = parseBool("insecure_skip_verify")
+	return opts, errors.Join(errs...)
+}
+
+func newS3FS(bucket string, opts S3Options) (*s3FS, error) {
+	cfg := aws.NewConfig()
+	if opts.Endpoint != "" {
+		cfg.WithEndpoint(opts.Endpoint)
+	}
+	if opts.Region != "" {
+		cfg.WithRegion(opts.Region)
+	}
+	if aws.BoolValue(opts.PathStyle) {
+		cfg.WithS3ForcePathStyle(true)
+	}
+	if aws.BoolValue(opts.InsecureSkipVerify) {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		cfg.WithHTTPClient(&http.Client{Transport: transport})
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *cfg,
+		Profile:           opts.Profile,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if opts.Endpoint != "" && aws.StringValue(sess.Config.Region) == "" {
+		// NOTE: S3 compatible storages ignore regions but requests are signed with the region.
+		sess.Config.WithRegion("us-east-1")
+	}
+	return newS3FSWithAPI(bucket, s3.New(sess)), nil
+}
+
+func newS3FSWithAPI(bucket string, api s3iface.S3API) *s3FS {
+	return &s3FS{
+		S3FS:   s3fs.NewWithAPI(bucket, api),
+		api:    api,
+		bucket: bucket,
+	}
+}
+
+// Stat returns a FileInfo describing the file. The Sys() of the FileInfo of an object returns *s3.HeadObjectOutput.
+func (fsys *s3FS) Stat(name string) (fs.FileInfo, error) {
+	if name == "." || !fs.ValidPath(name) {
+		return fsys.S3FS.Stat(name)
+	}
+	output, err := fsys.api.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(fsys.bucket),
+		Key:    aws.String(path.Clean(name)),
+	})
+	if err != nil {
+		// NOTE: The name may be a directory.
+		return fsys.S3FS.Stat(name)
+	}
+	return &objectInfo{
+		name:    path.Base(name),
+		size:    aws.Int64Value(output.ContentLength),
+		modTime: aws.TimeValue(output.LastModified),
+		sys:     output,
+	}, nil
+}
+
+// OpenRange opens the named object to read length bytes from offset using the Range header.
+func (fsys *s3FS) OpenRange(name string, offset, length int64) (io.ReadCloser, error) {
+	if length == 0 {
+		return io.NopCloser(&io.LimitedReader{}), nil
+	}
+	rng := fmt.Sprintf("bytes=%d-", offset)
+	if length > 0 {
+		rng = fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	}
+	output, err := fsys.api.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(fsys.bucket),
+		Key:    aws.String(path.Clean(name)),
+		Range:  aws.String(rng),
+	})
+	if err != nil {
+		// NOTE: S3 responds 416 if the offset is at or past the end of the object such as an empty object.
+		var rf awserr.RequestFailure
+		if errors.As(err, &rf) && rf.StatusCode() == http.StatusRequestedRangeNotSatisfiable {
+			return io.NopCloser(&io.LimitedReader{}), nil
+		}
+		return nil, &fs.PathError{Op: "OpenRange", Path: name, Err: err}
+	}
+	return output.Body, nil
+}
+
+func s3Metadata(output *s3.HeadObjectOutput) map[string]any {
+	storageClass := aws.StringValue(output.StorageClass)
+	if storageClass == "" {
+		storageClass = s3.StorageClassStandard
+	}
+	m := map[string]any{
+		"etag":         aws.StringValue(output.ETag),
+		"storageClass": storageClass,
+		"contentType":  aws.StringValue(output.ContentType),
+		"metadata":     aws.StringValueMap(output.Metadata),
+	}
+	if output.ContentEncoding != nil {
+		m["contentEncoding"] = aws.StringValue(output.ContentEncoding)
+	}
+	if output.VersionId != nil {
+		m["versionId"] = aws.StringValue(output.VersionId)
+	}
+	return m
+}
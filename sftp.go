@@ -0,0 +1,365 @@
+package fssh
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jarxorg/wfs"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	sftpDefaultPort = "22"
+	sftpDialTimeout = 30 * time.Second
+)
+
+// sftpKeyFiles are private key files in ~/.ssh used if ssh-agent does not have the key.
+var sftpKeyFiles = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// sftpClients holds hosts by "user@host:port" to share a connection between file systems that
+// are created by each command.
+var sftpClients = struct {
+	sync.Mutex
+	m map[string]*sftpHost
+}{m: map[string]*sftpHost{}}
+
+// sftpHost holds the connected client of a host. The mutex is locked while connecting to the host
+// so that connecting to other hosts is not blocked.
+type sftpHost struct {
+	sync.Mutex
+	c *sftp.Client
+}
+
+// sftpFS is a file system of a SFTP server. Names are absolute paths on the server.
+type sftpFS struct {
+	user string
+	addr string
+}
+
+var (
+	_ fs.ReadDirFS     = (*sftpFS)(nil)
+	_ fs.StatFS        = (*sftpFS)(nil)
+	_ wfs.RemoveFileFS = (*sftpFS)(nil)
+	_ AppendFileFS     = (*sftpFS)(nil)
+	_ RenameFS         = (*sftpFS)(nil)
+	_ RangeReaderFS    = (*sftpFS)(nil)
+)
+
+// newSFTPFS returns a file system of the host that is formatted as "[user@]host[:port]".
+// The connection is established at the first access.
+func newSFTPFS(host string) *sftpFS {
+	username, addr := "", host
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		username, addr = host[:i], host[i+1:]
+	}
+	if username == "" {
+		if u, err := user.Current(); err == nil {
+			username = u.Username
+		}
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(strings.Trim(addr, "[]"), sftpDefaultPort)
+	}
+	return &sftpFS{user: username, addr: addr}
+}
+
+// client returns the shared client of the host. The client is created at the first call.
+func (fsys *sftpFS) client() (*sftp.Client, error) {
+	key := fsys.user + "@" + fsys.addr
+	sftpClients.Lock()
+	h, ok := sftpClients.m[key]
+	if !ok {
+		h = &sftpHost{}
+		sftpClients.m[key] = h
+	}
+	sftpClients.Unlock()
+
+	h.Lock()
+	defer h.Unlock()
+	if h.c != nil {
+		return h.c, nil
+	}
+	conn, err := dialSSH(fsys.user, fsys.addr)
+	if err != nil {
+		return nil, err
+	}
+	c, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	h.c = c
+	go func() {
+		// NOTE: Forget the client if the connection is lost to reconnect at the next call.
+		c.Wait()
+		conn.Close()
+		h.Lock()
+		defer h.Unlock()
+		if h.c == c {
+			h.c = nil
+		}
+	}()
+	return c, nil
+}
+
+// Same reports whether the other is a file system of the same user and host to rename
+// files between URLs of the host.
+func (fsys *sftpFS) Same(other FS) bool {
+	o, ok := other.(*sftpFS)
+	return ok && o.user == fsys.user && o.addr == fsys.addr
+}
+
+// dialSSH connects to the addr with keys of ssh-agent and ~/.ssh. The host key is verified with
+// ~/.ssh/known_hosts.
+func dialSSH(username, addr string) (*ssh.Client, error) {
+	homeDir, err := osUserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	sshDir := filepath.Join(homeDir, ".ssh")
+	hostKeyCallback, err := knownhosts.New(filepath.Join(sshDir, "known_hosts"))
+	if err != nil {
+		return nil, err
+	}
+	var signers []ssh.Signer
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if agentConn, err := net.Dial("unix", sock); err == nil {
+			// NOTE: Signers of the agent use the connection until the handshake is completed.
+			defer agentConn.Close()
+			if agentSigners, err := agent.NewClient(agentConn).Signers(); err == nil {
+				signers = append(signers, agentSigners...)
+			}
+		}
+	}
+	for _, name := range sftpKeyFiles {
+		pem, err := os.ReadFile(filepath.Join(sshDir, name))
+		if err != nil {
+			continue
+		}
+		// NOTE: Keys protected by passphrases are skipped. Add them to ssh-agent to use.
+		signer, err := ssh.ParsePrivateKey(pem)
+		if err != nil {
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("no ssh keys in ssh-agent or %s", sshDir)
+	}
+	return ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            username,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signers...)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         sftpDialTimeout,
+	})
+}
+
+// do calls fn with the client and the absolute path of the name and returns the error as fs.PathError.
+func (fsys *sftpFS) do(op, name string, fn func(c *sftp.Client, p string) error) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	c, err := fsys.client()
+	if err == nil {
+		err = fn(c, path.Join("/", name))
+	}
+	if err != nil {
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	return nil
+}
+
+// Open opens the named file.
+func (fsys *sftpFS) Open(name string) (fs.File, error) {
+	var f fs.File
+	err := fsys.do("open", name, func(c *sftp.Client, p string) error {
+		info, err := c.Stat(p)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			f = &dirFile{fsys: fsys, name: name, info: info}
+			return nil
+		}
+		file, err := c.Open(p)
+		if err != nil {
+			return err
+		}
+		f = file
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// ReadDir reads the named directory and returns a list of directory entries sorted by filename.
+func (fsys *sftpFS) ReadDir(dir string) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	err := fsys.do("readdir", dir, func(c *sftp.Client, p string) error {
+		infos, err := c.ReadDir(p)
+		if err != nil {
+			return err
+		}
+		entries = make([]fs.DirEntry, len(infos))
+		for i, info := range infos {
+			entries[i] = fs.FileInfoToDirEntry(info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// Stat returns a FileInfo describing the file. The Sys() of the FileInfo returns *sftp.FileStat.
+func (fsys *sftpFS) Stat(name string) (fs.FileInfo, error) {
+	var info fs.FileInfo
+	err := fsys.do("stat", name, func(c *sftp.Client, p string) error {
+		var err error
+		info, err = c.Stat(p)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// MkdirAll creates the named directory and parents.
+func (fsys *sftpFS) MkdirAll(dir string, mode fs.FileMode) error {
+	return fsys.do("mkdir", dir, func(c *sftp.Client, p string) error {
+		return c.MkdirAll(p)
+	})
+}
+
+// CreateFile creates the named file. Parent directories are created if they do not exist.
+func (fsys *sftpFS) CreateFile(name string, mode fs.FileMode) (wfs.WriterFile, error) {
+	f, err := fsys.openFile("create", name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// AppendFile opens the named file for appending. If the file does not exist then this creates it.
+func (fsys *sftpFS) AppendFile(name string, mode fs.FileMode) (wfs.WriterFile, error) {
+	f, err := fsys.openFile("append", name, os.O_WRONLY|os.O_CREATE|os.O_APPEND)
+	if err != nil {
+		return nil, err
+	}
+	// NOTE: Writes have offsets and some servers ignore the append flag.
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		f.Close()
+		return nil, &fs.PathError{Op: "append", Path: name, Err: err}
+	}
+	return f, nil
+}
+
+func (fsys *sftpFS) openFile(op, name string, flag int) (*sftp.File, error) {
+	var f *sftp.File
+	err := fsys.do(op, name, func(c *sftp.Client, p string) error {
+		if err := c.MkdirAll(path.Dir(p)); err != nil {
+			return err
+		}
+		var err error
+		f, err = c.OpenFile(p, flag)
+		return err
+	})
+	return f, err
+}
+
+// WriteFile writes the specified bytes to the named file.
+func (fsys *sftpFS) WriteFile(name string, p []byte, mode fs.FileMode) (int, error) {
+	f, err := fsys.CreateFile(name, mode)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return f.Write(p)
+}
+
+// RemoveFile removes the named file or empty directory.
+func (fsys *sftpFS) RemoveFile(name string) error {
+	return fsys.do("remove", name, func(c *sftp.Client, p string) error {
+		return c.Remove(p)
+	})
+}
+
+// RemoveAll removes the named file and any children it contains.
+// If the file does not exist then this returns nil like os.RemoveAll.
+func (fsys *sftpFS) RemoveAll(name string) error {
+	return fsys.do("removeall", name, func(c *sftp.Client, p string) error {
+		if err := c.RemoveAll(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	})
+}
+
+// Rename renames (moves) oldname to newname. If the server supports posix-rename@openssh.com
+// then this replaces the existing newname.
+func (fsys *sftpFS) Rename(oldname, newname string) error {
+	if !fs.ValidPath(newname) {
+		return &fs.PathError{Op: "rename", Path: newname, Err: fs.ErrInvalid}
+	}
+	return fsys.do("rename", oldname, func(c *sftp.Client, p string) error {
+		newp := path.Join("/", newname)
+		if err := c.MkdirAll(path.Dir(newp)); err != nil {
+			return err
+		}
+		if _, ok := c.HasExtension("posix-rename@openssh.com"); ok {
+			return c.PosixRename(p, newp)
+		}
+		return c.Rename(p, newp)
+	})
+}
+
+// OpenRange opens the named file to read length bytes from offset.
+func (fsys *sftpFS) OpenRange(name string, offset, length int64) (io.ReadCloser, error) {
+	var f *sftp.File
+	err := fsys.do("openrange", name, func(c *sftp.Client, p string) error {
+		var err error
+		if f, err = c.Open(p); err != nil {
+			return err
+		}
+		if _, err = f.Seek(offset, io.SeekStart); err != nil {
+			f.Close()
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if length < 0 {
+		return f, nil
+	}
+	return &limitedReadCloser{Reader: io.LimitReader(f, length), Closer: f}, nil
+}
+
+func sftpMetadata(stat *sftp.FileStat) map[string]any {
+	return map[string]any{
+		"uid": stat.UID,
+		"gid": stat.GID,
+	}
+}
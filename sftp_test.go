@@ -0,0 +1,371 @@
+package fssh
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testSFTPServer is an in-process SFTP server that serves an in-memory file system.
+type testSFTPServer struct {
+	addr    string
+	hostKey ssh.PublicKey
+	mutex   sync.Mutex
+	conns   []net.Conn
+}
+
+// startTestSFTPServer starts a SFTP server that accepts the authorizedKey. The server is closed
+// at the end of the test.
+func startTestSFTPServer(t *testing.T, authorizedKey ssh.PublicKey) *testSFTPServer {
+	hostKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(authorizedKey.Marshal()) {
+				return nil, errors.New("unauthorized key")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testSFTPServer{addr: l.Addr().String(), hostKey: hostSigner.PublicKey()}
+	t.Cleanup(func() {
+		l.Close()
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		for _, conn := range s.conns {
+			conn.Close()
+		}
+	})
+	go func() {
+		handler := sftp.InMemHandler()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.mutex.Lock()
+			s.conns = append(s.conns, conn)
+			s.mutex.Unlock()
+			go s.serve(conn, config, handler)
+		}
+	}()
+	return s
+}
+
+func (s *testSFTPServer) serve(conn net.Conn, config *ssh.ServerConfig, handler sftp.Handlers) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChan := range chans {
+		if newChan.ChannelType() != "session" {
+			newChan.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		ch, reqs, err := newChan.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range reqs {
+				req.Reply(req.Type == "subsystem" && strings.HasSuffix(string(req.Payload), "sftp"), nil)
+			}
+		}()
+		go func() {
+			server := sftp.NewRequestServer(ch, handler)
+			server.Serve()
+			server.Close()
+		}()
+	}
+}
+
+// setTestSSHHome sets up the home directory that has the known_hosts of the hostKey and
+// the private key in id_ecdsa if the key is not nil.
+func setTestSSHHome(t *testing.T, addr string, hostKey ssh.PublicKey, key *ecdsa.PrivateKey) {
+	homeDir := t.TempDir()
+	sshDir := filepath.Join(homeDir, ".ssh")
+	if err := os.MkdirAll(sshDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, hostKey)
+	if err := os.WriteFile(filepath.Join(sshDir, "known_hosts"), []byte(line+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if key != nil {
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bin := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+		if err := os.WriteFile(filepath.Join(sshDir, "id_ecdsa"), bin, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	osUserHomeDirOrg := osUserHomeDir
+	osUserHomeDir = func() (string, error) {
+		return homeDir, nil
+	}
+	t.Cleanup(func() { osUserHomeDir = osUserHomeDirOrg })
+	t.Setenv("SSH_AUTH_SOCK", "")
+}
+
+func newTestClientKey(t *testing.T) (*ecdsa.PrivateKey, ssh.PublicKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, pub
+}
+
+func TestNewSFTPFS(t *testing.T) {
+	tests := []struct {
+		host     string
+		wantUser string
+		wantAddr string
+	}{
+		{
+			host:     "user@example.com",
+			wantUser: "user",
+			wantAddr: "example.com:22",
+		}, {
+			host:     "user@example.com:2222",
+			wantUser: "user",
+			wantAddr: "example.com:2222",
+		}, {
+			host:     "user@[::1]",
+			wantUser: "user",
+			wantAddr: "[::1]:22",
+		},
+	}
+	for i, test := range tests {
+		got := newSFTPFS(test.host)
+		if got.user != test.wantUser {
+			t.Errorf("tests[%d]: got user %s; want %s", i, got.user, test.wantUser)
+		}
+		if got.addr != test.wantAddr {
+			t.Errorf("tests[%d]: got addr %s; want %s", i, got.addr, test.wantAddr)
+		}
+	}
+}
+
+func TestSFTPFS(t *testing.T) {
+	key, pub := newTestClientKey(t)
+	s := startTestSFTPServer(t, pub)
+	setTestSSHHome(t, s.addr, s.hostKey, key)
+
+	fsys, _, _, _, err := NewFS("sftp://user@" + s.addr + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.WriteFile("dir/a.txt", []byte("abc"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.WriteFile("dir/sub/b.txt", []byte("def"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(fsys, "dir/a.txt", "dir/sub/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := AppendFile(fsys, "dir/a.txt", os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("ghi")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := OpenRange(fsys, "dir/a.txt", 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "cgh" {
+		t.Errorf("got %q; want %q", got, "cgh")
+	}
+
+	// NOTE: NewFS creates a file system for each URL of the host.
+	toFS, _, _, _, err := NewFS("sftp://user@" + s.addr + "/to")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Rename(fsys, "dir/a.txt", toFS, "to/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.Stat(fsys, "dir/a.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got err %v; want %v", err, fs.ErrNotExist)
+	}
+	got, err = fs.ReadFile(fsys, "to/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "abcghi" {
+		t.Errorf("got %q; want %q", got, "abcghi")
+	}
+
+	for _, name := range []string{"dir", "to", "none"} {
+		if err := fsys.(*sftpFS).RemoveAll(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("got %d entries; want 0", len(entries))
+	}
+}
+
+func TestSFTPFSClientNotBlocked(t *testing.T) {
+	key, pub := newTestClientKey(t)
+	s := startTestSFTPServer(t, pub)
+	setTestSSHHome(t, s.addr, s.hostKey, key)
+
+	// NOTE: Connecting to another host holds the lock of the host.
+	connecting := newSFTPFS("user@connecting.example.com")
+	h := &sftpHost{}
+	h.Lock()
+	defer h.Unlock()
+	sftpClients.Lock()
+	sftpClients.m[connecting.user+"@"+connecting.addr] = h
+	sftpClients.Unlock()
+	defer func() {
+		sftpClients.Lock()
+		delete(sftpClients.m, connecting.user+"@"+connecting.addr)
+		sftpClients.Unlock()
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := newSFTPFS("user@" + s.addr).client()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("blocked by connecting to another host")
+	}
+}
+
+func TestSFTPFSAgent(t *testing.T) {
+	key, pub := newTestClientKey(t)
+	s := startTestSFTPServer(t, pub)
+	setTestSSHHome(t, s.addr, s.hostKey, nil)
+
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: key}); err != nil {
+		t.Fatal(err)
+	}
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go agent.ServeAgent(keyring, conn)
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", sock)
+
+	fsys := newSFTPFS("user@" + s.addr)
+	if err := fsys.MkdirAll("dir", os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	info, err := fs.Stat(fsys, "dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.IsDir() {
+		t.Errorf("dir is not a directory")
+	}
+}
+
+func TestSFTPFSErrors(t *testing.T) {
+	key, pub := newTestClientKey(t)
+	s := startTestSFTPServer(t, pub)
+	otherKey, otherPub := newTestClientKey(t)
+
+	tests := []struct {
+		user    string
+		hostKey ssh.PublicKey
+		key     *ecdsa.PrivateKey
+		errstr  string
+	}{
+		{
+			user:    "mismatch",
+			hostKey: otherPub,
+			key:     key,
+			errstr:  "knownhosts: key mismatch",
+		}, {
+			user:    "unauthorized",
+			hostKey: s.hostKey,
+			key:     otherKey,
+			errstr:  "unable to authenticate",
+		}, {
+			user:    "nokey",
+			hostKey: s.hostKey,
+			errstr:  "no ssh keys in ssh-agent or ",
+		},
+	}
+	for i, test := range tests {
+		setTestSSHHome(t, s.addr, test.hostKey, test.key)
+		_, err := fs.Stat(newSFTPFS(test.user+"@"+s.addr), ".")
+		if err == nil {
+			t.Fatalf("tests[%d]: no error; want %s", i, test.errstr)
+		}
+		if !strings.Contains(err.Error(), test.errstr) {
+			t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+		}
+	}
+}
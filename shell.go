@@ -1,13 +1,21 @@
 package fssh
 
 import (
+	"bufio"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
+	"os"
+	"os/signal"
 	"path"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 
 	"github.com/chzyer/readline"
 	"github.com/jarxorg/wfs"
@@ -19,10 +27,18 @@ const ShellName = "fssh"
 // ErrExit represents an exit error. If this error is detected then the shell will terminate.
 var ErrExit = errors.New("exit")
 
+// ErrCommandFailed represents that one or more commands of a script failed.
+var ErrCommandFailed = errors.New("command failed")
+
 // Shell reads stdin, interprets lines, and executes commands.
 type Shell struct {
 	rl *readline.Instance
+	// rlStdout is the stdout of the terminal to detect redirections and pipelines.
+	rlStdout io.Writer
+	// rlStderr is the stderr of the terminal to detect redirections.
+	rlStderr io.Writer
 
+	Stdin         io.Reader
 	Stdout        io.Writer
 	Stderr        io.Writer
 	FS            wfs.WriteFileFS
@@ -30,26 +46,24 @@ type Shell struct {
 	Host          string
 	Dir           string
 	PrefixMatcher PrefixMatcher
+	// ExitOnError stops executing commands at the first error like "set -e".
+	ExitOnError bool
+	// Format is the default output format of listing and metadata commands like "set -format json".
+	Format Format
+	// s3Queries keeps options of S3 set by URL queries such as "?endpoint=" while the session.
+	s3Queries *s3QueryOptions
 }
 
-// NewShell creates a new Shell.
+// NewShell creates a new interactive Shell.
 func NewShell(dirUrl string) (*Shell, error) {
 	homeDir, err := osUserHomeDir()
 	if err != nil {
 		return nil, err
 	}
-
-	fsys, protocol, host, dir, err := NewFS(dirUrl)
+	sh, err := newShell(dirUrl)
 	if err != nil {
 		return nil, err
 	}
-	sh := &Shell{
-		FS:            fsys,
-		Protocol:      protocol,
-		Host:          host,
-		Dir:           dir,
-		PrefixMatcher: &GlobPrefixMatcher{},
-	}
 	rl, err := readline.NewEx(&readline.Config{
 		HistoryFile:       filepath.Join(homeDir, fmt.Sprintf(".%s_history", ShellName)),
 		AutoComplete:      newReadlineAutoCompleter(sh),
@@ -61,32 +75,148 @@ func NewShell(dirUrl string) (*Shell, error) {
 		return nil, err
 	}
 	sh.rl = rl
-	sh.Stdout = rl.Stdout()
-	sh.Stderr = rl.Stderr()
+	sh.rlStdout = rl.Stdout()
+	sh.Stdout = sh.rlStdout
+	sh.rlStderr = rl.Stderr()
+	sh.Stderr = sh.rlStderr
 	sh.UpdatePrompt()
 	return sh, nil
 }
 
+// NewScriptShell creates a new Shell that executes commands without readline.
+func NewScriptShell(dirUrl string) (*Shell, error) {
+	sh, err := newShell(dirUrl)
+	if err != nil {
+		return nil, err
+	}
+	sh.Stdout = os.Stdout
+	sh.Stderr = os.Stderr
+	return sh, nil
+}
+
+func newShell(dirUrl string) (*Shell, error) {
+	// NOTE: Report errors of the configuration at the start instead of every command.
+	if _, err := LoadConfig(); err != nil {
+		return nil, err
+	}
+	s3Queries := &s3QueryOptions{}
+	fsys, protocol, host, dir, err := newFS(dirUrl, s3Queries)
+	if err != nil {
+		return nil, err
+	}
+	return &Shell{
+		Stdin:         os.Stdin,
+		FS:            fsys,
+		Protocol:      protocol,
+		Host:          host,
+		Dir:           dir,
+		PrefixMatcher: &GlobPrefixMatcher{},
+		s3Queries:     s3Queries,
+	}, nil
+}
+
 // DirWithProtocol returns the current directory held by the shell.
 func (sh *Shell) DirWithProtocol() string {
 	return sh.Protocol + path.Join(sh.Host, sh.Dir)
 }
 
+// ResolveArchivePath returns the URL of the name in an archive that is relative to the current
+// directory such as "build.zip!/bin". Other names are returned as is.
+func (sh *Shell) ResolveArchivePath(nameUrl string) string {
+	if _, _, ok := splitArchivePath(nameUrl); !ok {
+		return nameUrl
+	}
+	if strings.HasPrefix(nameUrl, "~") || strings.HasPrefix(nameUrl, "/") || strings.Contains(nameUrl, ":/") {
+		return nameUrl
+	}
+	return JoinURL(sh.DirWithProtocol(), nameUrl)
+}
+
+// isTerminal is readline.IsTerminal that is replaced by unit tests.
+var isTerminal = readline.IsTerminal
+
+// IsTerminal reports whether the shell is interactive and the stderr is a terminal.
+// If the stderr is redirected such as "2> log.txt" then this returns false.
+func (sh *Shell) IsTerminal() bool {
+	return sh.rl != nil && sh.Stderr == sh.rlStderr && isTerminal(int(os.Stderr.Fd()))
+}
+
+// TerminalStreams returns the stdin, stdout and stderr for local processes. The stdout and stderr of
+// the terminal are replaced with os.Stdout and os.Stderr so that processes such as vi use the terminal.
+// Redirected and piped streams are returned as is.
+func (sh *Shell) TerminalStreams() (stdin io.Reader, stdout io.Writer, stderr io.Writer) {
+	stdin, stdout, stderr = sh.Stdin, sh.Stdout, sh.Stderr
+	if sh.rl == nil {
+		return
+	}
+	if stdout == sh.rlStdout {
+		stdout = os.Stdout
+	}
+	if stderr == sh.rlStderr {
+		stderr = os.Stderr
+	}
+	return
+}
+
+// ScreenWidth returns the width of the terminal if the stdout is the terminal otherwise 0.
+func (sh *Shell) ScreenWidth() int {
+	if sh.rl == nil || sh.Stdout != sh.rlStdout || !isTerminal(int(os.Stdout.Fd())) {
+		return 0
+	}
+	if w := readline.GetScreenWidth(); w > 0 {
+		return w
+	}
+	return 0
+}
+
 // UpdatePrompt updates the command line prompt.
 func (sh *Shell) UpdatePrompt() {
 	sh.PrefixMatcher.Reset()
+	if sh.rl == nil {
+		return
+	}
 	sh.rl.SetPrompt("\033[36m" + sh.DirWithProtocol() + ">\033[0m ")
 }
 
 // Close closes the shell.
 func (sh *Shell) Close() error {
+	if sh.rl == nil {
+		return nil
+	}
 	return sh.rl.Close()
 }
 
-// Run runs the shell.
+// Run runs the shell. SIGINT cancels the context of the running command and SIGTERM closes the shell.
 func (sh *Shell) Run() error {
-	sh.rl.CaptureExitSignal()
 	log.SetOutput(sh.Stderr)
+
+	var mutex sync.Mutex
+	var cancel context.CancelFunc
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	stopped := make(chan struct{})
+	defer close(stopped)
+	go func() {
+		for {
+			select {
+			case <-stopped:
+				return
+			case sig := <-signals:
+				if sig != os.Interrupt {
+					sh.rl.Close()
+					continue
+				}
+				// NOTE: Readline receives Ctrl-C as a key while waiting for input.
+				mutex.Lock()
+				if cancel != nil {
+					cancel()
+				}
+				mutex.Unlock()
+			}
+		}
+	}()
+
 	for {
 		line, err := sh.rl.Readline()
 		if err == readline.ErrInterrupt {
@@ -98,18 +228,210 @@ func (sh *Shell) Run() error {
 		} else if err == io.EOF {
 			break
 		}
-		if err := sh.ExecCommand(ParseArgs(line)); err != nil {
+		ctx, cancelLine := context.WithCancel(context.Background())
+		mutex.Lock()
+		cancel = cancelLine
+		mutex.Unlock()
+
+		err = sh.ExecLine(ctx, line)
+
+		mutex.Lock()
+		cancel = nil
+		mutex.Unlock()
+		cancelLine()
+
+		if errors.Is(err, ErrExit) {
+			return nil
+		}
+		if ctx.Err() != nil {
+			// NOTE: Start a new line after "^C" echoed by the terminal.
+			fmt.Fprintln(sh.Stderr)
+			if err != nil {
+				fmt.Fprintf(sh.Stderr, "%s: interrupted\n", ShellName)
+			}
+			continue
+		}
+		if err != nil {
+			sh.printError(err)
+		}
+	}
+	return nil
+}
+
+// RunScript executes each line read from r. Empty lines and lines starting with "#" are ignored.
+// If any command fails then this returns ErrCommandFailed. If the ctx is cancelled then this stops.
+func (sh *Shell) RunScript(ctx context.Context, r io.Reader) error {
+	failed := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := sh.ExecLine(ctx, line); err != nil {
 			if errors.Is(err, ErrExit) {
-				return nil
+				break
+			}
+			sh.printError(err)
+			failed = true
+			if sh.ExitOnError || ctx.Err() != nil {
+				break
 			}
-			fmt.Fprintf(sh.Stderr, "%s: %v\n", ShellName, err)
 		}
 	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if failed {
+		return ErrCommandFailed
+	}
 	return nil
 }
 
-// ExecCommand executes a command.
-func (sh *Shell) ExecCommand(args []string) error {
+// ExecLine executes commands in the specified line separated by ";".
+// The returned error joins errors of all failed commands. Commands after the ctx is cancelled are not executed.
+func (sh *Shell) ExecLine(ctx context.Context, line string) error {
+	var errs []error
+	for _, cmdLine := range SplitCommands(line) {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		err := sh.ExecPipeline(ctx, cmdLine)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, ErrExit) {
+			return err
+		}
+		errs = append(errs, err)
+		if sh.ExitOnError {
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ExecPipeline executes commands in the specified line connected by "|".
+// The stdout of each command is connected to the stdin of the next command.
+func (sh *Shell) ExecPipeline(ctx context.Context, line string) error {
+	cmdLines := SplitPipeline(line)
+	if len(cmdLines) <= 1 {
+		return sh.ExecRedirect(ctx, line)
+	}
+
+	errs := make([]error, len(cmdLines))
+	done := make([]atomic.Bool, len(cmdLines)+1)
+	wg := &sync.WaitGroup{}
+	stdin := sh.Stdin
+	for i, cmdLine := range cmdLines {
+		// NOTE: Each command runs on a copy of the shell like a subshell.
+		sub := *sh
+		sub.Stdin = stdin
+		var pw *io.PipeWriter
+		if i < len(cmdLines)-1 {
+			stdin, pw = io.Pipe()
+			sub.Stdout = pw
+		}
+		wg.Add(1)
+		go func(i int, cmdLine string, sub *Shell, pw *io.PipeWriter) {
+			defer wg.Done()
+			err := sub.ExecRedirect(ctx, cmdLine)
+			done[i].Store(true)
+			if pw != nil {
+				pw.Close()
+			}
+			if r, ok := sub.Stdin.(*io.PipeReader); ok {
+				// NOTE: Unblock the previous command if this command did not read all.
+				r.Close()
+			}
+			// NOTE: Ignore errors like SIGPIPE if the next command has already finished.
+			if err != nil && !done[i+1].Load() {
+				errs[i] = err
+			}
+		}(i, cmdLine, &sub, pw)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// ExecRedirect executes a command in the specified line with redirections "<", ">", ">>", "2>" and "2>&1".
+// Redirect targets are resolved by SubFS so any file system is available.
+func (sh *Shell) ExecRedirect(ctx context.Context, line string) (err error) {
+	cmdLine, redirects, err := ParseRedirects(line)
+	if err != nil {
+		return err
+	}
+	if len(redirects) == 0 {
+		return sh.ExecCommand(ctx, ParseArgs(cmdLine))
+	}
+	stdin, stdout, stderr := sh.Stdin, sh.Stdout, sh.Stderr
+	defer func() {
+		sh.Stdin, sh.Stdout, sh.Stderr = stdin, stdout, stderr
+	}()
+	for _, r := range redirects {
+		if r.Op == ">&" {
+			// NOTE: Like POSIX shells the current target is duplicated so the order matters.
+			w := sh.Stdout
+			if r.URL == "2" {
+				w = sh.Stderr
+			}
+			if r.Fd == 2 {
+				sh.Stderr = w
+			} else {
+				sh.Stdout = w
+			}
+			continue
+		}
+		f, e := sh.openRedirect(r)
+		if e != nil {
+			return e
+		}
+		defer func() {
+			// NOTE: Object storages upload the content on Close.
+			if e := f.Close(); e != nil && err == nil {
+				err = e
+			}
+		}()
+		switch {
+		case r.Op == "<":
+			sh.Stdin = f.(io.Reader)
+		case r.Fd == 2:
+			sh.Stderr = f.(io.Writer)
+		default:
+			sh.Stdout = f.(io.Writer)
+		}
+	}
+	return sh.ExecCommand(ctx, ParseArgs(cmdLine))
+}
+
+// openRedirect opens the redirect target. The returned file is fs.File if the op is "<" otherwise wfs.WriterFile.
+func (sh *Shell) openRedirect(r Redirect) (io.Closer, error) {
+	fsys, name, err := sh.SubFS(r.URL)
+	if err != nil {
+		return nil, err
+	}
+	switch r.Op {
+	case "<":
+		return fsys.Open(name)
+	case ">>":
+		return AppendFile(fsys, name, os.ModePerm)
+	}
+	return fsys.CreateFile(name, os.ModePerm)
+}
+
+func (sh *Shell) printError(err error) {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			sh.printError(e)
+		}
+		return
+	}
+	fmt.Fprintf(sh.Stderr, "%s: %v\n", ShellName, err)
+}
+
+// ExecCommand executes a command. The ctx is passed to the command to cancel it.
+func (sh *Shell) ExecCommand(ctx context.Context, args []string) error {
 	if len(args) == 0 {
 		return nil
 	}
@@ -126,7 +448,7 @@ func (sh *Shell) ExecCommand(args []string) error {
 		}
 		return err
 	}
-	return cmd.Exec(sh)
+	return cmd.Exec(ctx, sh)
 }
 
 // Usage prints the usage to the specified writer..
@@ -141,24 +463,56 @@ func (sh *Shell) Usage(w io.Writer) {
 
 // SubFS returns the FS and related path. If the dirUrl has protocol then this creates a new FS.
 func (sh *Shell) SubFS(filenameUrl string) (FS, string, error) {
+	filenameUrl = sh.ResolveArchivePath(filenameUrl)
 	if IsCurrentPath(filenameUrl) {
 		return sh.FS, path.Join(sh.Dir, filenameUrl), nil
 	}
-	fsys, _, _, filename, err := NewFS(filenameUrl)
+	fsys, _, _, filename, err := sh.NewFS(filenameUrl)
 	if err != nil {
 		return nil, "", err
 	}
 	return fsys, filename, nil
 }
 
+// AbsURL returns the URL of the filenameUrl that does not depend on the current directory.
+// Local paths are converted to absolute paths.
+func (sh *Shell) AbsURL(filenameUrl string) (string, error) {
+	filenameUrl = sh.ResolveArchivePath(filenameUrl)
+	protocol, host, filename := sh.Protocol, sh.Host, path.Join(sh.Dir, filenameUrl)
+	if !IsCurrentPath(filenameUrl) {
+		var err error
+		protocol, host, filename, err = ParseURI(filenameUrl)
+		if err != nil {
+			return "", err
+		}
+	}
+	if protocol == "" {
+		return filepath.Abs(filepath.Join(host, filepath.FromSlash(filename)))
+	}
+	return protocol + path.Join(host, filename), nil
+}
+
 // SubFS returns the FS and related path. If the dirUrl has protocol then this creates a new FS.
 func (sh *Shell) SubDirFS(dirUrl string) (FS, string, error) {
+	dirUrl = sh.ResolveArchivePath(dirUrl)
 	if IsCurrentPath(dirUrl) {
 		return sh.FS, path.Join(sh.Dir, dirUrl), nil
 	}
-	fsys, _, _, dir, err := NewDirFS(dirUrl)
+	fsys, _, _, dir, err := sh.NewDirFS(dirUrl)
 	if err != nil {
 		return nil, "", err
 	}
 	return fsys, dir, nil
 }
+
+// NewFS creates a new FS like NewFS. Options of S3 set by URL queries are kept in the shell and
+// used for later URLs of the bucket.
+func (sh *Shell) NewFS(filenameUrl string) (fsys FS, protocol string, host string, filename string, err error) {
+	return newFS(filenameUrl, sh.s3Queries)
+}
+
+// NewDirFS creates a new FS of the directory like NewDirFS. Options of S3 set by URL queries are kept
+// in the shell and used for later URLs of the bucket.
+func (sh *Shell) NewDirFS(dirUrl string) (fsys FS, protocol string, host string, dir string, err error) {
+	return newDirFS(dirUrl, sh.s3Queries)
+}
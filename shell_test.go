@@ -2,9 +2,14 @@ package fssh
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"flag"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -133,7 +138,7 @@ func TestShellExecCommand(t *testing.T) {
 		},
 	}
 	for i, test := range tests {
-		err := sh.ExecCommand(test.args)
+		err := sh.ExecCommand(context.Background(), test.args)
 		if test.errstr != "" {
 			if err == nil {
 				t.Fatalf("tests[%d]: no error; want %s", i, test.errstr)
@@ -149,6 +154,354 @@ func TestShellExecCommand(t *testing.T) {
 	}
 }
 
+func TestShellExecLine(t *testing.T) {
+	var got []string
+	RegisterNewCommandFunc(func() Command {
+		echoCmd := &testCommand{name: "echo"}
+		echoCmd.execFunc = func(ctx context.Context, sh *Shell) error {
+			got = append(got, strings.Join(echoCmd.FlagSet().Args(), " "))
+			return nil
+		}
+		return echoCmd
+	})
+	defer DeregisterNewCommandFunc("echo")
+	RegisterNewCommandFunc(func() Command {
+		return &testCommand{
+			name: "fail",
+			execFunc: func(ctx context.Context, sh *Shell) error {
+				return errors.New("test-error")
+			},
+		}
+	})
+	defer DeregisterNewCommandFunc("fail")
+	var cancel context.CancelFunc
+	RegisterNewCommandFunc(func() Command {
+		return &testCommand{
+			name: "interrupt",
+			execFunc: func(ctx context.Context, sh *Shell) error {
+				cancel()
+				return ctx.Err()
+			},
+		}
+	})
+	defer DeregisterNewCommandFunc("interrupt")
+
+	sh, err := NewScriptShell("mem://")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		line        string
+		exitOnError bool
+		want        []string
+		errstr      string
+	}{
+		{
+			line: "echo a; echo 'b;c'",
+			want: []string{"a", "b;c"},
+		}, {
+			line:   "echo a; fail; echo b; fail",
+			want:   []string{"a", "b"},
+			errstr: "test-error\ntest-error",
+		}, {
+			line:        "echo a; fail; echo b",
+			exitOnError: true,
+			want:        []string{"a"},
+			errstr:      "test-error",
+		}, {
+			line:   "echo a; unknown",
+			want:   []string{"a"},
+			errstr: "command not found: unknown",
+		}, {
+			line:   "echo a; interrupt; echo b",
+			want:   []string{"a"},
+			errstr: "context canceled\ncontext canceled",
+		},
+	}
+	for i, test := range tests {
+		got = nil
+		sh.ExitOnError = test.exitOnError
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		err := sh.ExecLine(ctx, test.line)
+		cancel()
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d]: no error; want %s", i, test.errstr)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+		} else if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("tests[%d]: got %v; want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestShellExecPipeline(t *testing.T) {
+	RegisterNewCommandFunc(func() Command {
+		echoCmd := &testCommand{name: "echo"}
+		echoCmd.execFunc = func(ctx context.Context, sh *Shell) error {
+			_, err := fmt.Fprintln(sh.Stdout, strings.Join(echoCmd.FlagSet().Args(), " "))
+			return err
+		}
+		return echoCmd
+	})
+	defer DeregisterNewCommandFunc("echo")
+	RegisterNewCommandFunc(func() Command {
+		return &testCommand{
+			name: "upper",
+			execFunc: func(ctx context.Context, sh *Shell) error {
+				bin, err := io.ReadAll(sh.Stdin)
+				if err != nil {
+					return err
+				}
+				_, err = sh.Stdout.Write(bytes.ToUpper(bin))
+				return err
+			},
+		}
+	})
+	defer DeregisterNewCommandFunc("upper")
+	RegisterNewCommandFunc(func() Command {
+		return &testCommand{
+			name: "yes",
+			execFunc: func(ctx context.Context, sh *Shell) error {
+				for {
+					if _, err := fmt.Fprintln(sh.Stdout, "y"); err != nil {
+						return err
+					}
+				}
+			},
+		}
+	})
+	defer DeregisterNewCommandFunc("yes")
+	RegisterNewCommandFunc(func() Command {
+		return &testCommand{
+			name: "fail",
+			execFunc: func(ctx context.Context, sh *Shell) error {
+				return errors.New("test-error")
+			},
+		}
+	})
+	defer DeregisterNewCommandFunc("fail")
+
+	sh, err := NewScriptShell("mem://")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		line   string
+		want   string
+		errstr string
+	}{
+		{
+			line: "echo a",
+			want: "a\n",
+		}, {
+			line: "echo a | upper",
+			want: "A\n",
+		}, {
+			line: "echo 'a|b' | upper | upper",
+			want: "A|B\n",
+		}, {
+			line: "yes | echo a",
+			want: "a\n",
+		}, {
+			line:   "fail | upper",
+			errstr: "test-error",
+		},
+	}
+	for i, test := range tests {
+		stdout := new(bytes.Buffer)
+		sh.Stdout = stdout
+
+		err := sh.ExecPipeline(context.Background(), test.line)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d]: no error; want %s", i, test.errstr)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+		} else if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+		if got := stdout.String(); got != test.want {
+			t.Errorf("tests[%d]: got %q; want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestShellExecRedirect(t *testing.T) {
+	RegisterNewCommandFunc(func() Command {
+		echoCmd := &testCommand{name: "echo"}
+		echoCmd.execFunc = func(ctx context.Context, sh *Shell) error {
+			_, err := fmt.Fprintln(sh.Stdout, strings.Join(echoCmd.FlagSet().Args(), " "))
+			return err
+		}
+		return echoCmd
+	})
+	defer DeregisterNewCommandFunc("echo")
+	RegisterNewCommandFunc(func() Command {
+		return &testCommand{
+			name: "upper",
+			execFunc: func(ctx context.Context, sh *Shell) error {
+				bin, err := io.ReadAll(sh.Stdin)
+				if err != nil {
+					return err
+				}
+				_, err = sh.Stdout.Write(bytes.ToUpper(bin))
+				return err
+			},
+		}
+	})
+	defer DeregisterNewCommandFunc("upper")
+	RegisterNewCommandFunc(func() Command {
+		warnCmd := &testCommand{name: "warn"}
+		warnCmd.execFunc = func(ctx context.Context, sh *Shell) error {
+			fmt.Fprintln(sh.Stdout, "out")
+			_, err := fmt.Fprintln(sh.Stderr, "err")
+			return err
+		}
+		return warnCmd
+	})
+	defer DeregisterNewCommandFunc("warn")
+
+	sh, err := NewScriptShell("mem://")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := new(bytes.Buffer)
+	sh.Stdout = stdout
+	stderr := new(bytes.Buffer)
+	sh.Stderr = stderr
+
+	tests := []struct {
+		line   string
+		errstr string
+	}{
+		{
+			line: "echo a > out.txt",
+		}, {
+			line: "echo b >> out.txt",
+		}, {
+			line: "upper < out.txt >> dir/upper.txt",
+		}, {
+			line: "warn > warn.txt 2>&1",
+		}, {
+			line: "warn 2> err.txt > out2.txt",
+		}, {
+			line:   "upper < not-found.txt",
+			errstr: "Open not-found.txt: file does not exist",
+		},
+	}
+	for i, test := range tests {
+		err := sh.ExecRedirect(context.Background(), test.line)
+		if test.errstr != "" {
+			if err == nil {
+				t.Fatalf("tests[%d]: no error; want %s", i, test.errstr)
+			}
+			if err.Error() != test.errstr {
+				t.Errorf("tests[%d]: got err %v; want %s", i, err, test.errstr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d]: err %v", i, err)
+		}
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("got stdout %q; want empty", stdout.String())
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("got stderr %q; want empty", stderr.String())
+	}
+	if sh.Stdout != stdout || sh.Stderr != stderr {
+		t.Errorf("stdout or stderr was not restored")
+	}
+	for name, want := range map[string]string{"warn.txt": "out\nerr\n", "err.txt": "err\n", "out2.txt": "out\n"} {
+		got, err := fs.ReadFile(sh.FS, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s: got %q; want %q", name, got, want)
+		}
+	}
+	got, err := fs.ReadFile(sh.FS, "dir/upper.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "A\nB\n"; string(got) != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestShellRunScript(t *testing.T) {
+	RegisterNewCommandFunc(func() Command {
+		return &testCommand{
+			name: "fail",
+			execFunc: func(ctx context.Context, sh *Shell) error {
+				return errors.New("test-error")
+			},
+		}
+	})
+	defer DeregisterNewCommandFunc("fail")
+	RegisterNewCommandFunc(func() Command {
+		return &testCommand{
+			name: "exit",
+			execFunc: func(ctx context.Context, sh *Shell) error {
+				return ErrExit
+			},
+		}
+	})
+	defer DeregisterNewCommandFunc("exit")
+
+	tests := []struct {
+		script      string
+		exitOnError bool
+		wantStderr  string
+		err         error
+	}{
+		{
+			script: "# comment\n\n",
+		}, {
+			script:     "fail\nfail",
+			wantStderr: "fssh: test-error\nfssh: test-error\n",
+			err:        ErrCommandFailed,
+		}, {
+			script:      "fail\nfail",
+			exitOnError: true,
+			wantStderr:  "fssh: test-error\n",
+			err:         ErrCommandFailed,
+		}, {
+			script: "exit\nfail",
+		},
+	}
+	for i, test := range tests {
+		sh, err := NewScriptShell("mem://")
+		if err != nil {
+			t.Fatal(err)
+		}
+		stderr := new(bytes.Buffer)
+		sh.Stderr = stderr
+		sh.ExitOnError = test.exitOnError
+
+		err = sh.RunScript(context.Background(), strings.NewReader(test.script))
+		if err != test.err {
+			t.Errorf("tests[%d]: got err %v; want %v", i, err, test.err)
+		}
+		if got := stderr.String(); got != test.wantStderr {
+			t.Errorf("tests[%d]: got stderr %q; want %q", i, got, test.wantStderr)
+		}
+	}
+}
+
 func TestShellUsage(t *testing.T) {
 	done := setupTestNewShell(t)
 	defer done()
@@ -0,0 +1,90 @@
+package fssh
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// TaskGroup runs tasks concurrently with a limited number of workers.
+// If a task fails then the context of the group is canceled and no more tasks are started.
+// Running tasks receive the canceled context so they should stop and remove partially written files.
+type TaskGroup struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	sem    chan struct{}
+	wg     sync.WaitGroup
+	mutex  sync.Mutex
+	n      int
+	errs   map[int]error
+}
+
+// NewTaskGroup creates a new TaskGroup with the number of workers. If workers is less than 1 then 1 is used.
+func NewTaskGroup(ctx context.Context, workers int) *TaskGroup {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &TaskGroup{
+		ctx:    ctx,
+		cancel: cancel,
+		sem:    make(chan struct{}, workers),
+		errs:   map[int]error{},
+	}
+}
+
+// Context returns the context that is canceled at the first error.
+func (g *TaskGroup) Context() context.Context {
+	return g.ctx
+}
+
+// Go runs the task in a worker. This blocks while all workers are busy.
+// If the group has been canceled then this returns false without running the task.
+func (g *TaskGroup) Go(task func(ctx context.Context) error) bool {
+	select {
+	case <-g.ctx.Done():
+		return false
+	case g.sem <- struct{}{}:
+	}
+	if g.ctx.Err() != nil {
+		<-g.sem
+		return false
+	}
+	g.mutex.Lock()
+	i := g.n
+	g.n++
+	g.mutex.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer func() {
+			<-g.sem
+			g.wg.Done()
+		}()
+		if err := task(g.ctx); err != nil {
+			g.mutex.Lock()
+			g.errs[i] = err
+			g.mutex.Unlock()
+			g.cancel()
+		}
+	}()
+	return true
+}
+
+// Wait waits for all running tasks and returns errors joined in the order the tasks were started.
+// If the parent context was canceled then the error of the context is also returned.
+func (g *TaskGroup) Wait() error {
+	g.wg.Wait()
+	defer g.cancel()
+
+	var errs []error
+	for i := 0; i < g.n; i++ {
+		if err, ok := g.errs[i]; ok {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return g.ctx.Err()
+	}
+	return errors.Join(errs...)
+}
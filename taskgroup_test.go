@@ -0,0 +1,79 @@
+package fssh
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTaskGroup(t *testing.T) {
+	var running, maxRunning int32
+	g := NewTaskGroup(context.Background(), 2)
+	for i := 0; i < 10; i++ {
+		ok := g.Go(func(ctx context.Context) error {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			return nil
+		})
+		if !ok {
+			t.Fatalf("tasks[%d]: not started", i)
+		}
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if maxRunning != 2 {
+		t.Errorf("got max running %d; want %d", maxRunning, 2)
+	}
+}
+
+func TestTaskGroupErrors(t *testing.T) {
+	errFirst := errors.New("first")
+	errSecond := errors.New("second")
+	release := make(chan struct{})
+
+	g := NewTaskGroup(context.Background(), 2)
+	g.Go(func(ctx context.Context) error {
+		<-release
+		return errFirst
+	})
+	g.Go(func(ctx context.Context) error {
+		defer close(release)
+		return errSecond
+	})
+	<-g.Context().Done()
+
+	started := g.Go(func(ctx context.Context) error {
+		return nil
+	})
+	if started {
+		t.Errorf("got started %v; want %v", started, false)
+	}
+	err := g.Wait()
+	want := "first\nsecond"
+	if err == nil || err.Error() != want {
+		t.Errorf("got err %v; want %s", err, want)
+	}
+}
+
+func TestTaskGroupCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	g := NewTaskGroup(ctx, 1)
+	if g.Go(func(ctx context.Context) error { return nil }) {
+		t.Errorf("got started; want not started")
+	}
+	if err := g.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("got err %v; want %v", err, context.Canceled)
+	}
+}
@@ -2,11 +2,13 @@ package fssh
 
 import (
 	"fmt"
+	"io"
 	"math"
 	"net/url"
 	"os"
 	"path"
 	"strings"
+	"sync"
 
 	gobsargs "github.com/gobs/args"
 )
@@ -16,8 +18,11 @@ var osUserHomeDir = func() (string, error) {
 	return os.UserHomeDir()
 }
 
-// IsCurrentPath checks the specified name has ":/"
+// IsCurrentPath checks the specified name has ":/" or the separator of an archive.
 func IsCurrentPath(name string) bool {
+	if _, _, ok := splitArchivePath(name); ok {
+		return false
+	}
 	return !strings.HasPrefix(name, "~") && !strings.Contains(name, ":/")
 }
 
@@ -26,10 +31,174 @@ func ParseArgs(line string) []string {
 	return gobsargs.GetArgs(line)
 }
 
+// SplitCommands splits the specified line into commands separated by ";".
+// Separators in quotes or escaped by backslash are ignored.
+func SplitCommands(line string) []string {
+	return splitUnquoted(line, ';')
+}
+
+// SplitPipeline splits the specified line into commands connected by "|".
+// Separators in quotes or escaped by backslash are ignored.
+func SplitPipeline(line string) []string {
+	return splitUnquoted(line, '|')
+}
+
+// Redirect represents an input or output redirection of a command.
+type Redirect struct {
+	// Fd is the file descriptor to redirect. 0 is stdin, 1 is stdout and 2 is stderr.
+	Fd int
+	// Op is one of "<", ">", ">>" or ">&". ">&" duplicates the file descriptor of the URL like "2>&1".
+	Op string
+	// URL is the url of the redirect target or the file descriptor "1" or "2" if the Op is ">&".
+	URL string
+}
+
+// ParseRedirects extracts redirections from the specified line and returns the rest of the line.
+// Redirections in quotes or escaped by backslash are ignored.
+func ParseRedirects(line string) (string, []Redirect, error) {
+	var redirects []Redirect
+	var rest []rune
+	rs := []rune(line)
+	var quote rune
+	escape := false
+	for i := 0; i < len(rs); i++ {
+		c := rs[i]
+		switch {
+		case escape:
+			escape = false
+		case c == '\\' && quote != '`':
+			escape = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '<' || c == '>':
+			fd := 0
+			if c == '>' {
+				fd = 1
+			}
+			// NOTE: A digit at the beginning of a word is the file descriptor like "2>".
+			if i > 0 && rs[i-1] >= '0' && rs[i-1] <= '9' && (i == 1 || rs[i-2] == ' ' || rs[i-2] == '\t') {
+				fd = int(rs[i-1] - '0')
+				rest = rest[:len(rest)-1]
+			}
+			op := string(c)
+			if c == '>' && i+1 < len(rs) && rs[i+1] == '>' {
+				op = ">>"
+				i++
+			} else if i+1 < len(rs) && rs[i+1] == '&' {
+				op = string(c) + "&"
+				i++
+			}
+			if (c == '<' && fd != 0) || (c == '>' && fd != 1 && fd != 2) {
+				return "", nil, fmt.Errorf("syntax error: unsupported redirect %d%s", fd, op)
+			}
+			word, n := scanWord(rs[i+1:])
+			args := ParseArgs(word)
+			if len(args) == 0 {
+				return "", nil, fmt.Errorf("syntax error: missing redirect target of %s", op)
+			}
+			if op == "<&" || (op == ">&" && args[0] != "1" && args[0] != "2") {
+				return "", nil, fmt.Errorf("syntax error: unsupported redirect %s%s", op, args[0])
+			}
+			redirects = append(redirects, Redirect{Fd: fd, Op: op, URL: args[0]})
+			rest = append(rest, ' ')
+			i += n
+			continue
+		}
+		rest = append(rest, c)
+	}
+	return strings.TrimSpace(string(rest)), redirects, nil
+}
+
+// scanWord returns the first word of rs and the number of scanned runes.
+func scanWord(rs []rune) (string, int) {
+	var quote rune
+	escape := false
+	start := -1
+	i := 0
+	for ; i < len(rs); i++ {
+		c := rs[i]
+		if start == -1 {
+			if c == ' ' || c == '\t' {
+				continue
+			}
+			start = i
+		}
+		switch {
+		case escape:
+			escape = false
+		case c == '\\' && quote != '`':
+			escape = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == ' ' || c == '\t' || c == '<' || c == '>':
+			return string(rs[start:i]), i
+		}
+	}
+	if start == -1 {
+		return "", i
+	}
+	return string(rs[start:i]), i
+}
+
+// splitUnquoted splits s by sep outside of quotes. Empty items are removed.
+func splitUnquoted(s string, sep rune) []string {
+	var items []string
+	var quote rune
+	escape := false
+	start := 0
+	appendItem := func(item string) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	for i, c := range s {
+		switch {
+		case escape:
+			escape = false
+		case c == '\\' && quote != '`':
+			escape = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == sep:
+			appendItem(s[start:i])
+			start = i + 1
+		}
+	}
+	appendItem(s[start:])
+	return items
+}
+
 // ParseURI parses the specified uri to protocol, host, filename.
 // If the uri starts with ~~ it is replaced with the local current filename.
 // If the uri starts with ~, it is replaced with the local home filename.
+// If the uri is a name in an archive such as build.zip!/bin, the host includes the archive.
+// If the uri starts with the name of a remote such as prod:/logs, it is replaced with the URL of the remote.
 func ParseURI(uri string) (protocol, host, filename string, err error) {
+	if archiveUrl, name, ok := splitArchivePath(uri); ok {
+		// NOTE: The archive is a part of the host like "s3://" + "bucket/build.zip!" + "/bin".
+		protocol, host, filename, err = ParseURI(archiveUrl)
+		if err != nil {
+			return
+		}
+		host = path.Join(host, filename) + ArchiveSeparator
+		filename = name
+		return
+	}
+	if uri, err = ResolveRemote(uri); err != nil {
+		return
+	}
 	if strings.HasPrefix(uri, "~") {
 		if strings.HasPrefix(uri[1:], "~") {
 			host = "."
@@ -51,20 +220,43 @@ func ParseURI(uri string) (protocol, host, filename string, err error) {
 		return
 	}
 	switch u.Scheme {
-	case "s3", "gs", "mem":
+	case "s3", "gs", "mem", "http", "https":
+		protocol = u.Scheme + "://"
+		host = u.Host
+		filename = path.Clean(strings.TrimLeft(u.Path, "/"))
+	case "sftp":
 		protocol = u.Scheme + "://"
 		host = u.Host
+		if u.User != nil {
+			// NOTE: The password is not kept in the host to avoid printing it in the prompt.
+			host = u.User.Username() + "@" + host
+		}
 		filename = path.Clean(strings.TrimLeft(u.Path, "/"))
 	case "file":
 		host = u.Host
 		filename = path.Clean(strings.TrimLeft(u.Path, "/"))
 	default:
 		host = "."
+		if strings.HasPrefix(uri, "/") {
+			host = "/"
+		}
 		filename = path.Clean(strings.TrimLeft(uri, "/"))
 	}
 	return
 }
 
+// JoinURL joins the base url and the relative name with "/".
+// Unlike path.Join this keeps "://" of the base url.
+func JoinURL(base, name string) string {
+	if name == "" || name == "." {
+		return base
+	}
+	if base == "" {
+		return name
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimLeft(name, "/")
+}
+
 const (
 	unitKb = 1024
 	unitMb = 1024 * 1024
@@ -125,3 +317,24 @@ func SliceClone[T comparable](src []T) []T {
 	copy(dest, src)
 	return dest
 }
+
+// SyncWriter serializes writes to the writer so that workers of a command can write lines to the
+// stdout of the shell. Each line should be written by a single Write such as fmt.Fprintf.
+type SyncWriter struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+var _ io.Writer = (*SyncWriter)(nil)
+
+// NewSyncWriter returns a SyncWriter of the writer.
+func NewSyncWriter(w io.Writer) *SyncWriter {
+	return &SyncWriter{w: w}
+}
+
+// Write writes p to the writer holding the lock.
+func (w *SyncWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.w.Write(p)
+}